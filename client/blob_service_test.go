@@ -0,0 +1,620 @@
+package client_test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"hash/crc64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client"
+	"github.com/cloudfoundry/bosh-azure-storage-cli/config"
+
+	. "github.com/onsi/gomega"
+)
+
+// blobService is a stand-in for the parts of the blob service which are
+// needed to list, read, copy and delete block blobs of one container. It
+// serves path style URLs of the form /<account>/<container>/<blob>, like
+// Azurite does.
+type blobService struct {
+	account   string
+	container string
+	server    *httptest.Server
+
+	mutex sync.Mutex
+	blobs map[string]serviceBlob
+	etags int
+	// corruptCopies makes server-side copies write different content than
+	// they read, like a copy which went wrong unnoticed.
+	corruptCopies bool
+	// pendingCopies makes server-side copies stay pending until they are
+	// aborted.
+	pendingCopies bool
+	copyPolls     []time.Time
+	abortedCopies []string
+	// blocks are the staged blocks of each blob by their ID.
+	blocks map[string]map[string]string
+	// corruptUploads and corruptDownloads change the content of staged
+	// blocks and downloaded ranges after their CRC64 was computed.
+	corruptUploads   bool
+	corruptDownloads bool
+}
+
+type serviceBlob struct {
+	content      string
+	etag         string
+	lastModified time.Time
+	// md5 is the Content-MD5 property. The service only computes it for
+	// content it receives, a server-side copy takes it over from the source.
+	md5 string
+	// properties are the content headers and x-ms-meta-* headers the blob
+	// is served with.
+	properties http.Header
+	// blockIDs are the IDs of the committed blocks of the blob, in order.
+	blockIDs []string
+	// copyID and copyStatus describe the server-side copy which created
+	// the blob, if any.
+	copyID     string
+	copyStatus string
+	// length is reported instead of the length of content by HEAD when set,
+	// so that copies of the blob take the path for large blobs.
+	length int
+}
+
+// contentHeaders are the properties Set Blob Properties replaces, by their
+// request header.
+var contentHeaders = map[string]string{
+	"x-ms-blob-content-type":        "Content-Type",
+	"x-ms-blob-content-encoding":    "Content-Encoding",
+	"x-ms-blob-content-language":    "Content-Language",
+	"x-ms-blob-content-disposition": "Content-Disposition",
+	"x-ms-blob-cache-control":       "Cache-Control",
+}
+
+func newBlobService(account string, container string) *blobService {
+	service := &blobService{account: account, container: container, blobs: map[string]serviceBlob{}, blocks: map[string]map[string]string{}}
+	service.server = httptest.NewServer(http.HandlerFunc(service.serve))
+	return service
+}
+
+// URL is the service_url of the account.
+func (s *blobService) URL() string {
+	return s.server.URL + "/" + s.account
+}
+
+// Config returns a configuration for the container of the service.
+func (s *blobService) Config() config.AZStorageConfig {
+	return config.AZStorageConfig{
+		AccountName:   s.account,
+		AccountKey:    base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("k"), 64)),
+		ContainerName: s.container,
+		ServiceURL:    s.URL(),
+	}
+}
+
+// Blobstore returns a blobstore which uses the container of the service
+// through DefaultStorageClient.
+func (s *blobService) Blobstore() client.AzBlobstore {
+	return s.BlobstoreWith(s.Config())
+}
+
+// BlobstoreWith is Blobstore with a configuration derived from Config.
+func (s *blobService) BlobstoreWith(storageConfig config.AZStorageConfig) client.AzBlobstore {
+	storageClient, err := client.NewStorageClient(storageConfig)
+	Expect(err).ToNot(HaveOccurred())
+	blobstore, err := client.New(storageClient)
+	Expect(err).ToNot(HaveOccurred())
+	return blobstore
+}
+
+func (s *blobService) Close() {
+	s.server.Close()
+}
+
+func (s *blobService) Put(name string, content string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.put(name, content)
+}
+
+// PutLarge adds a blob which reports length as its size, so that copies of
+// it are done server-side instead of with Copy Blob From URL.
+func (s *blobService) PutLarge(name string, content string, length int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	blob := s.put(name, content)
+	blob.length = length
+	s.blobs[name] = blob
+}
+
+// SetProperties sets headers the blob is served with, e.g. Content-Type or
+// x-ms-meta-sha256.
+func (s *blobService) SetProperties(name string, properties http.Header) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for key, values := range properties {
+		s.blobs[name].properties[key] = values
+	}
+}
+
+// Properties returns the headers the blob is served with.
+func (s *blobService) Properties(name string) http.Header {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.blobs[name].properties.Clone()
+}
+
+// CorruptCopies makes server-side copies write different content than they
+// read, while keeping the Content-MD5 of their source.
+func (s *blobService) CorruptCopies() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.corruptCopies = true
+}
+
+// PendCopies makes server-side copies stay pending until they are aborted.
+func (s *blobService) PendCopies() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pendingCopies = true
+}
+
+// CopyPolls returns the times at which the properties of blobs with a
+// pending copy were read.
+func (s *blobService) CopyPolls() []time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]time.Time(nil), s.copyPolls...)
+}
+
+// AbortedCopies returns the IDs of the aborted copies.
+func (s *blobService) AbortedCopies() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.abortedCopies...)
+}
+
+// CorruptUploads makes staged blocks differ from what was sent, as if they
+// were corrupted on the way.
+func (s *blobService) CorruptUploads() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.corruptUploads = true
+}
+
+// CorruptDownloads makes downloaded ranges differ from the content their
+// CRC64 is computed of, as if they were corrupted on the way.
+func (s *blobService) CorruptDownloads() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.corruptDownloads = true
+}
+
+// BlockIDs returns the IDs of the committed blocks of a blob.
+func (s *blobService) BlockIDs(name string) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.blobs[name].blockIDs...)
+}
+
+// MD5 returns the Content-MD5 property of a blob.
+func (s *blobService) MD5(name string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.blobs[name].md5
+}
+
+// Contents returns the content of every blob by name.
+func (s *blobService) Contents() map[string]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	contents := map[string]string{}
+	for name, blob := range s.blobs {
+		contents[name] = blob.content
+	}
+	return contents
+}
+
+func (s *blobService) put(name string, content string) serviceBlob {
+	return s.store(name, content, contentMD5(content), http.Header{})
+}
+
+func (s *blobService) store(name string, content string, md5 string, properties http.Header) serviceBlob {
+	s.etags++
+	blob := serviceBlob{
+		content:      content,
+		etag:         fmt.Sprintf("0x%X", s.etags),
+		lastModified: time.Now().UTC(),
+		md5:          md5,
+		properties:   properties,
+	}
+	s.blobs[name] = blob
+	return blob
+}
+
+func (s *blobService) serve(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	containerPath := "/" + s.account + "/" + s.container
+	query := r.URL.Query()
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "SharedKey "+s.account+":") && query.Get("sig") == "" {
+		serviceError(w, http.StatusForbidden, "AuthenticationFailed")
+		return
+	}
+
+	switch {
+	case r.URL.Path == containerPath && r.Method == http.MethodGet && query.Get("comp") == "list":
+		s.list(w, query.Get("prefix"))
+	case strings.HasPrefix(r.URL.Path, containerPath+"/"):
+		name := strings.TrimPrefix(r.URL.Path, containerPath+"/")
+		switch r.Method {
+		case http.MethodHead, http.MethodGet:
+			s.get(w, r, name)
+		case http.MethodPut:
+			s.putBlob(w, r, name)
+		case http.MethodDelete:
+			s.delete(w, r, name)
+		default:
+			serviceError(w, http.StatusMethodNotAllowed, "UnsupportedHttpVerb")
+		}
+	default:
+		serviceError(w, http.StatusBadRequest, "UnsupportedOperation")
+	}
+}
+
+type enumerationResults struct {
+	XMLName       xml.Name     `xml:"EnumerationResults"`
+	ContainerName string       `xml:"ContainerName,attr"`
+	Prefix        string       `xml:"Prefix"`
+	Blobs         []listedBlob `xml:"Blobs>Blob"`
+	NextMarker    string       `xml:"NextMarker"`
+}
+
+type listedBlob struct {
+	Name       string           `xml:"Name"`
+	Properties listedProperties `xml:"Properties"`
+}
+
+type listedProperties struct {
+	LastModified  string `xml:"Last-Modified"`
+	ETag          string `xml:"Etag"`
+	ContentLength int    `xml:"Content-Length"`
+	ContentMD5    string `xml:"Content-MD5"`
+	BlobType      string `xml:"BlobType"`
+}
+
+func (s *blobService) list(w http.ResponseWriter, prefix string) {
+	results := enumerationResults{ContainerName: s.container, Prefix: prefix}
+	for name, blob := range s.blobs {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		results.Blobs = append(results.Blobs, listedBlob{Name: name, Properties: listedProperties{
+			LastModified:  blob.lastModified.Format(http.TimeFormat),
+			ETag:          blob.etag,
+			ContentLength: len(blob.content),
+			ContentMD5:    blob.md5,
+			BlobType:      "BlockBlob",
+		}})
+	}
+	sort.Slice(results.Blobs, func(i, j int) bool { return results.Blobs[i].Name < results.Blobs[j].Name })
+
+	w.Header().Set("Content-Type", "application/xml")
+	fmt.Fprint(w, xml.Header)         //nolint:errcheck
+	xml.NewEncoder(w).Encode(results) //nolint:errcheck
+}
+
+func (s *blobService) get(w http.ResponseWriter, r *http.Request, name string) {
+	blob, ok := s.blobs[name]
+	if !ok {
+		serviceError(w, http.StatusNotFound, "BlobNotFound")
+		return
+	}
+	if blob.copyStatus == "pending" {
+		s.copyPolls = append(s.copyPolls, time.Now())
+	}
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && strings.Trim(ifMatch, `"`) != blob.etag {
+		serviceError(w, http.StatusPreconditionFailed, "ConditionNotMet")
+		return
+	}
+	setBlobHeaders(w, blob)
+
+	byteRange := r.Header.Get("x-ms-range")
+	if byteRange == "" {
+		byteRange = r.Header.Get("Range")
+	}
+	if r.Method == http.MethodGet && byteRange != "" {
+		s.getRange(w, r, blob, byteRange)
+		return
+	}
+
+	length := len(blob.content)
+	if r.Method == http.MethodHead && blob.length > 0 {
+		length = blob.length
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(length))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodGet {
+		io.WriteString(w, blob.content) //nolint:errcheck
+	}
+}
+
+// getRange serves the range "bytes=<first>-<last>" of blob, with its CRC64
+// if x-ms-range-get-content-crc64 asks for it.
+func (s *blobService) getRange(w http.ResponseWriter, r *http.Request, blob serviceBlob, byteRange string) {
+	var first, last int
+	_, err := fmt.Sscanf(byteRange, "bytes=%d-%d", &first, &last)
+	if err != nil || first > last || first >= len(blob.content) {
+		serviceError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange")
+		return
+	}
+	last = min(last, len(blob.content)-1)
+	data := []byte(blob.content[first : last+1])
+
+	if r.Header.Get("x-ms-range-get-content-crc64") == "true" {
+		w.Header().Set("x-ms-content-crc64", contentCRC64(data))
+	}
+	if s.corruptDownloads {
+		data[0] ^= 0xff
+	}
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", first, last, len(blob.content)))
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusPartialContent)
+	w.Write(data) //nolint:errcheck
+}
+
+func (s *blobService) putBlob(w http.ResponseWriter, r *http.Request, name string) {
+	switch {
+	case r.URL.Query().Get("comp") == "properties":
+		s.setProperties(w, r, name)
+	case r.URL.Query().Get("comp") == "copy":
+		s.abortCopy(w, r, name)
+	case r.URL.Query().Get("comp") == "block":
+		s.stageBlock(w, r, name)
+	case r.URL.Query().Get("comp") == "blocklist":
+		s.commitBlocks(w, r, name)
+	case r.Header.Get("x-ms-copy-source") != "":
+		s.copy(w, r, name)
+	default:
+		serviceError(w, http.StatusBadRequest, "UnsupportedHeader")
+	}
+}
+
+// copy implements Copy Blob From URL, which reads the source synchronously
+// and verifies it against x-ms-source-content-md5, and Copy Blob, which
+// completes right away here unless copies pend, but keeps the Content-MD5
+// of the source.
+func (s *blobService) copy(w http.ResponseWriter, r *http.Request, name string) {
+	synchronous := r.Header.Get("x-ms-requires-sync") == "true"
+	if !synchronous && s.pendingCopies {
+		blob := s.store(name, "", "", http.Header{})
+		blob.copyID = "copy-" + blob.etag
+		blob.copyStatus = "pending"
+		s.blobs[name] = blob
+		setBlobHeaders(w, blob)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	// The source may be served by this service, so it is read unlocked.
+	s.mutex.Unlock()
+	resp, err := http.Get(r.Header.Get("x-ms-copy-source")) //nolint:gosec,noctx
+	var content []byte
+	if err == nil {
+		content, err = io.ReadAll(resp.Body)
+		resp.Body.Close() //nolint:errcheck
+	}
+	s.mutex.Lock()
+	if err != nil || resp.StatusCode != http.StatusOK {
+		serviceError(w, http.StatusBadRequest, "CannotVerifyCopySource")
+		return
+	}
+	if sourceMD5 := r.Header.Get("x-ms-source-content-md5"); synchronous && sourceMD5 != "" && sourceMD5 != contentMD5(string(content)) {
+		serviceError(w, http.StatusBadRequest, "Md5Mismatch")
+		return
+	}
+
+	properties := http.Header{}
+	for _, header := range contentHeaders {
+		if value := resp.Header.Get(header); value != "" {
+			properties.Set(header, value)
+		}
+	}
+	metadata := r.Header
+	if !hasMetadata(metadata) {
+		metadata = resp.Header
+	}
+	for key, values := range metadata {
+		if strings.HasPrefix(strings.ToLower(key), "x-ms-meta-") {
+			properties[key] = values
+		}
+	}
+
+	md5 := contentMD5(string(content))
+	if !synchronous {
+		md5 = resp.Header.Get("Content-MD5")
+		if s.corruptCopies {
+			content = append(content, '!')
+		}
+	}
+	blob := s.store(name, string(content), md5, properties)
+	blob.copyID = "copy-" + blob.etag
+	blob.copyStatus = "success"
+	s.blobs[name] = blob
+	setBlobHeaders(w, blob)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// abortCopy implements Abort Copy Blob for pending copies.
+func (s *blobService) abortCopy(w http.ResponseWriter, r *http.Request, name string) {
+	blob, ok := s.blobs[name]
+	if !ok {
+		serviceError(w, http.StatusNotFound, "BlobNotFound")
+		return
+	}
+	copyID := r.URL.Query().Get("copyid")
+	if r.Header.Get("x-ms-copy-action") != "abort" || copyID != blob.copyID {
+		serviceError(w, http.StatusConflict, "CopyIdMismatch")
+		return
+	}
+	if blob.copyStatus != "pending" {
+		serviceError(w, http.StatusConflict, "NoPendingCopyOperation")
+		return
+	}
+	blob.copyStatus = "aborted"
+	s.blobs[name] = blob
+	s.abortedCopies = append(s.abortedCopies, copyID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// stageBlock implements Put Block, which validates the block against
+// x-ms-content-crc64 when it is sent.
+func (s *blobService) stageBlock(w http.ResponseWriter, r *http.Request, name string) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		serviceError(w, http.StatusBadRequest, "InvalidInput")
+		return
+	}
+	if s.corruptUploads && len(data) > 0 {
+		data[0] ^= 0xff
+	}
+	if crc := r.Header.Get("x-ms-content-crc64"); crc != "" && crc != contentCRC64(data) {
+		serviceError(w, http.StatusBadRequest, "Crc64Mismatch")
+		return
+	}
+	if s.blocks[name] == nil {
+		s.blocks[name] = map[string]string{}
+	}
+	s.blocks[name][r.URL.Query().Get("blockid")] = string(data)
+	w.WriteHeader(http.StatusCreated)
+}
+
+type blockList struct {
+	Latest []string `xml:"Latest"`
+}
+
+// commitBlocks implements Put Block List for staged blocks. Like the
+// service, it stores x-ms-blob-content-md5 as it is given.
+func (s *blobService) commitBlocks(w http.ResponseWriter, r *http.Request, name string) {
+	var list blockList
+	err := xml.NewDecoder(r.Body).Decode(&list)
+	if err != nil {
+		serviceError(w, http.StatusBadRequest, "InvalidXmlDocument")
+		return
+	}
+
+	var content strings.Builder
+	for _, blockID := range list.Latest {
+		block, ok := s.blocks[name][blockID]
+		if !ok {
+			serviceError(w, http.StatusBadRequest, "InvalidBlockList")
+			return
+		}
+		content.WriteString(block)
+	}
+	properties := http.Header{}
+	for key, values := range r.Header {
+		if strings.HasPrefix(strings.ToLower(key), "x-ms-meta-") {
+			properties[key] = values
+		}
+	}
+
+	blob := s.store(name, content.String(), r.Header.Get("x-ms-blob-content-md5"), properties)
+	blob.blockIDs = list.Latest
+	s.blobs[name] = blob
+	delete(s.blocks, name)
+	setBlobHeaders(w, blob)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// setProperties implements Set Blob Properties, which replaces every
+// content header, including those which are not sent.
+func (s *blobService) setProperties(w http.ResponseWriter, r *http.Request, name string) {
+	blob, ok := s.blobs[name]
+	if !ok {
+		serviceError(w, http.StatusNotFound, "BlobNotFound")
+		return
+	}
+	for header, property := range contentHeaders {
+		blob.properties.Del(property)
+		if value := r.Header.Get(header); value != "" {
+			blob.properties.Set(property, value)
+		}
+	}
+	blob.md5 = r.Header.Get("x-ms-blob-content-md5")
+	s.etags++
+	blob.etag = fmt.Sprintf("0x%X", s.etags)
+	s.blobs[name] = blob
+	setBlobHeaders(w, blob)
+	w.WriteHeader(http.StatusOK)
+}
+
+func hasMetadata(header http.Header) bool {
+	for key := range header {
+		if strings.HasPrefix(strings.ToLower(key), "x-ms-meta-") {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *blobService) delete(w http.ResponseWriter, r *http.Request, name string) {
+	blob, ok := s.blobs[name]
+	if !ok {
+		serviceError(w, http.StatusNotFound, "BlobNotFound")
+		return
+	}
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && strings.Trim(ifMatch, `"`) != blob.etag {
+		serviceError(w, http.StatusPreconditionFailed, "ConditionNotMet")
+		return
+	}
+	delete(s.blobs, name)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func setBlobHeaders(w http.ResponseWriter, blob serviceBlob) {
+	w.Header().Set("ETag", `"`+blob.etag+`"`)
+	w.Header().Set("Last-Modified", blob.lastModified.Format(http.TimeFormat))
+	if blob.md5 != "" {
+		w.Header().Set("Content-MD5", blob.md5)
+	}
+	w.Header().Set("x-ms-blob-type", "BlockBlob")
+	if blob.copyStatus != "" {
+		w.Header().Set("x-ms-copy-id", blob.copyID)
+		w.Header().Set("x-ms-copy-status", blob.copyStatus)
+	}
+	for key, values := range blob.properties {
+		w.Header()[key] = values
+	}
+}
+
+func serviceError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("x-ms-error-code", code)
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "%s<Error><Code>%s</Code><Message>%s</Message></Error>", xml.Header, code, code) //nolint:errcheck
+}
+
+var crc64Table = crc64.MakeTable(0x9A6C9329AC4BC9B5)
+
+// contentCRC64 is the x-ms-content-crc64 header of data.
+func contentCRC64(data []byte) string {
+	return base64.StdEncoding.EncodeToString(binary.LittleEndian.AppendUint64(nil, crc64.Checksum(data, crc64Table)))
+}
+
+func contentMD5(content string) string {
+	sum := md5.Sum([]byte(content))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+)
+
+// singleDeleteConcurrency is the number of blobs of a batch deleted in
+// parallel when the Blob Batch API is unavailable.
+const singleDeleteConcurrency = 16
+
+type DeleteRecursiveOptions struct {
+	// Concurrency is the number of batches deleted at the same time.
+	Concurrency int
+	// ContinueOnError keeps deleting the remaining blobs after a blob could
+	// not be deleted. Otherwise no further batches are started.
+	ContinueOnError bool
+}
+
+var errStopDeleting = errors.New("stop deleting")
+
+// DeleteRecursive deletes every blob below prefix that passes filter. Blobs
+// are deleted in batches of up to MaxBatchSize using the Blob Batch API,
+// falling back to parallel single deletes if the service refuses batches as
+// unsupported.
+// Blobs which could not be deleted are returned as BlobFailures.
+func (client *AzBlobstore) DeleteRecursive(prefix string, filter ListFilter, options DeleteRecursiveOptions) error {
+	if prefix != "" {
+		log.Printf("Deleting all matching blobs with prefix '%s'\n", prefix)
+	} else {
+		log.Println("Deleting all matching blobs")
+	}
+
+	deleted := startProgress("Deleted", "blobs")
+	var batchUnavailable atomic.Bool
+
+	var mutex sync.Mutex
+	failures := &BlobFailures{Operation: "delete", Errors: map[string]error{}}
+
+	batches := make(chan []string)
+	var wg sync.WaitGroup
+	for range max(options.Concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				batchFailures := client.deleteBatch(batch, &batchUnavailable)
+				deleted.add(len(batch) - len(batchFailures))
+
+				mutex.Lock()
+				for name, err := range batchFailures {
+					failures.Errors[name] = err
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	_, err := client.List(prefix, filter, ListOptions{}, func(items []*BlobItem) error {
+		for start := 0; start < len(items); start += MaxBatchSize {
+			end := min(start+MaxBatchSize, len(items))
+			batch := make([]string, 0, end-start)
+			for _, item := range items[start:end] {
+				batch = append(batch, *item.Name)
+			}
+			mutex.Lock()
+			failed := len(failures.Errors) > 0
+			mutex.Unlock()
+			if failed && !options.ContinueOnError {
+				return errStopDeleting
+			}
+
+			batches <- batch
+		}
+		return nil
+	})
+
+	close(batches)
+	wg.Wait()
+	deleted.stop()
+
+	if errors.Is(err, errStopDeleting) {
+		log.Println("Stopped deleting after the first failure")
+		err = nil
+	}
+	return failures.join(err)
+}
+
+// deleteBatch deletes names with a single batch request, or with parallel
+// single deletes once the service has refused a batch request as
+// unsupported. If the batch request fails for another reason, such as
+// throttling or a server error which persisted through the retries of the
+// SDK, every blob of the batch is reported with that error.
+func (client *AzBlobstore) deleteBatch(names []string, batchUnavailable *atomic.Bool) map[string]error {
+	if !batchUnavailable.Load() {
+		failures, err := client.storageClient.DeleteBatch(names)
+		if err == nil {
+			return failures
+		}
+		if !batchUnsupported(err) {
+			failures = make(map[string]error, len(names))
+			for _, name := range names {
+				failures[name] = err
+			}
+			return failures
+		}
+		if !batchUnavailable.Swap(true) {
+			log.Printf("Batch delete unavailable, falling back to single deletes: %s\n", errorSummary(err))
+		}
+	}
+
+	var mutex sync.Mutex
+	failures := map[string]error{}
+	forEachParallel(names, singleDeleteConcurrency, func(name string) {
+		if err := client.storageClient.Delete(name, DeleteOptions{}); err != nil {
+			mutex.Lock()
+			failures[name] = err
+			mutex.Unlock()
+		}
+	})
+	return failures
+}
+
+// featureNotSupported is returned by accounts which do not offer the Blob
+// Batch API, e.g. with a hierarchical namespace. bloberror has no constant
+// for it.
+const featureNotSupported bloberror.Code = "FeatureNotSupported"
+
+// batchUnsupported reports whether err means that the service does not
+// support Blob Batch requests at all, as opposed to failing this one.
+func batchUnsupported(err error) bool {
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotImplemented {
+		return true
+	}
+	return bloberror.HasCode(err,
+		featureNotSupported,
+		bloberror.InvalidQueryParameterValue,
+		bloberror.UnsupportedQueryParameter,
+		bloberror.UnsupportedHTTPVerb,
+	)
+}
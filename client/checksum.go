@@ -0,0 +1,41 @@
+package client
+
+import (
+	"hash"
+	"hash/crc64"
+	"io"
+)
+
+// crc64Table uses the polynomial of the CRC64 the blob service validates
+// transactions with.
+var crc64Table = crc64.MakeTable(0x9A6C9329AC4BC9B5)
+
+// sha256MetadataKey is the metadata key holding the hex encoded SHA-256 of
+// blobs uploaded with UploadOptions.SHA256.
+const sha256MetadataKey = "sha256"
+
+// hashingReader hashes everything read from source. Seeking back to the
+// start, as the SDK does before it retries a request, resets the hash so
+// that it only covers the bytes of the last attempt.
+type hashingReader struct {
+	source io.ReadSeekCloser
+	hash   hash.Hash
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.source.Read(p)
+	r.hash.Write(p[:n]) //nolint:errcheck
+	return n, err
+}
+
+func (r *hashingReader) Seek(offset int64, whence int) (int64, error) {
+	position, err := r.source.Seek(offset, whence)
+	if err == nil && position == 0 {
+		r.hash.Reset()
+	}
+	return position, err
+}
+
+func (r *hashingReader) Close() error {
+	return r.source.Close()
+}
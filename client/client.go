@@ -2,13 +2,18 @@ package client
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"fmt"
 	"io"
 	"log"
+	"net/url"
 	"os"
 	"strings"
 	"time"
+
+	azBlob "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
 )
 
 type AzBlobstore struct {
@@ -19,12 +24,7 @@ func New(storageClient StorageClient) (AzBlobstore, error) {
 	return AzBlobstore{storageClient: storageClient}, nil
 }
 
-func (client *AzBlobstore) Put(sourceFilePath string, dest string) error {
-	sourceMD5, err := client.getMD5(sourceFilePath)
-	if err != nil {
-		return err
-	}
-
+func (client *AzBlobstore) Put(sourceFilePath string, dest string, options UploadOptions) error {
 	source, err := os.Open(sourceFilePath)
 	if err != nil {
 		return err
@@ -32,15 +32,30 @@ func (client *AzBlobstore) Put(sourceFilePath string, dest string) error {
 
 	defer source.Close() //nolint:errcheck
 
-	md5, err := client.storageClient.Upload(source, dest)
+	if options.CRC64 || options.SHA256 {
+		// Every block is validated by the service against its CRC64. The
+		// service computes no MD5 of the committed blob to check against.
+		_, err = client.storageClient.Upload(source, dest, options)
+		if err != nil {
+			return fmt.Errorf("upload failure: %w", err)
+		}
+		log.Println("Successfully uploaded file")
+		return nil
+	}
+
+	// The MD5 of the file is computed while it is uploaded, so that the file
+	// is only read once.
+	hashedSource := &hashingReader{source: source, hash: md5.New()}
+	md5, err := client.storageClient.Upload(hashedSource, dest, options)
 	if err != nil {
 		return fmt.Errorf("upload failure: %w", err)
 	}
+	sourceMD5 := hashedSource.hash.Sum(nil)
 
 	if !bytes.Equal(sourceMD5, md5) {
 		log.Println("The upload failed because of an MD5 inconsistency. Triggering blob deletion ...")
 
-		err := client.storageClient.Delete(dest)
+		err := client.storageClient.Delete(dest, DeleteOptions{LeaseID: options.LeaseID})
 		if err != nil {
 			log.Println(fmt.Errorf("blob deletion failed: %w", err))
 		}
@@ -52,19 +67,97 @@ func (client *AzBlobstore) Put(sourceFilePath string, dest string) error {
 	return nil
 }
 
-func (client *AzBlobstore) Get(source string, dest *os.File) error {
+func (client *AzBlobstore) Get(source string, dest *os.File, options DownloadOptions) error {
+
+	return client.storageClient.Download(source, dest, options)
+}
+
+func (client *AzBlobstore) Delete(dest string, options DeleteOptions) error {
+
+	err := client.storageClient.Delete(dest, options)
+	if bloberror.HasCode(err, bloberror.SnapshotsPresent) {
+		return fmt.Errorf("blob %s has snapshots, delete them with the blob using snapshots option 'include' or on their own using 'only': %w", dest, err)
+	}
+	if bloberror.HasCode(err, bloberror.LeaseIDMissing) {
+		return fmt.Errorf("blob %s has an active lease, its lease ID is required to delete it: %w", dest, err)
+	}
+	return err
+}
+
+// Snapshot creates a snapshot of dest and returns its timestamp.
+func (client *AzBlobstore) Snapshot(dest string) (string, error) {
+
+	return client.storageClient.CreateSnapshot(dest)
+}
+
+// PromoteSnapshot restores dest to the content of its snapshot. The current
+// content of dest is overwritten.
+func (client *AzBlobstore) PromoteSnapshot(ctx context.Context, dest string, snapshot string) error {
+
+	return client.storageClient.PromoteSnapshot(ctx, dest, snapshot)
+}
+
+// Versions returns all versions of dest, oldest first, when blob versioning
+// is enabled on the account.
+func (client *AzBlobstore) Versions(dest string) ([]*BlobItem, error) {
+	var versions []*BlobItem
+	_, err := client.storageClient.List(dest, ListOptions{Include: ListBlobsInclude{Versions: true}}, func(items []*BlobItem) error {
+		for _, item := range items {
+			if blobName(item) == dest && item.VersionID != nil {
+				versions = append(versions, item)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// RestoreVersion makes the version versionID of dest its current version.
+// The current content of dest is kept as a new version.
+func (client *AzBlobstore) RestoreVersion(ctx context.Context, dest string, versionID string) error {
+
+	return client.storageClient.RestoreVersion(ctx, dest, versionID)
+}
+
+// InfiniteLease is the lease duration of a lease which never expires.
+const InfiniteLease = -1
+
+// AcquireLease acquires a lease of duration seconds (15 to 60, or
+// InfiniteLease) on dest and returns its lease ID. proposedID may be empty
+// to let the service choose the ID.
+func (client *AzBlobstore) AcquireLease(dest string, duration int32, proposedID string) (string, error) {
+	if duration != InfiniteLease && (duration < 15 || duration > 60) {
+		return "", fmt.Errorf("invalid lease duration %d, expected 15 to 60 seconds or infinite", duration)
+	}
+	return client.storageClient.AcquireLease(dest, duration, proposedID)
+}
+
+func (client *AzBlobstore) RenewLease(dest string, leaseID string) error {
 
-	return client.storageClient.Download(source, dest)
+	return client.storageClient.RenewLease(context.Background(), dest, leaseID)
 }
 
-func (client *AzBlobstore) Delete(dest string) error {
+func (client *AzBlobstore) ReleaseLease(dest string, leaseID string) error {
 
-	return client.storageClient.Delete(dest)
+	return client.storageClient.ReleaseLease(dest, leaseID)
 }
 
-func (client *AzBlobstore) DeleteRecursive(prefix string) error {
+// BreakLease breaks the lease on dest after at most breakPeriod seconds
+// (0 to 60), or after the remaining lease period for a negative breakPeriod.
+// It returns the seconds until the lease is broken.
+func (client *AzBlobstore) BreakLease(dest string, breakPeriod int32) (int32, error) {
+	if breakPeriod > 60 {
+		return 0, fmt.Errorf("invalid break period %d, expected 0 to 60 seconds", breakPeriod)
+	}
+	return client.storageClient.BreakLease(dest, max(breakPeriod, -1))
+}
 
-	return client.storageClient.DeleteRecursive(prefix)
+func (client *AzBlobstore) ChangeLease(dest string, leaseID string, proposedID string) (string, error) {
+
+	return client.storageClient.ChangeLease(dest, leaseID, proposedID)
 }
 
 func (client *AzBlobstore) Exists(dest string) (bool, error) {
@@ -99,13 +192,82 @@ func (client *AzBlobstore) getMD5(filePath string) ([]byte, error) {
 	return hash.Sum(nil), nil
 }
 
-func (client *AzBlobstore) List(prefix string) ([]string, error) {
-	return client.storageClient.List(prefix)
+// List streams the blobs below prefix that pass filter to handlePage one
+// page at a time. It returns a continuation token when the listing was cut
+// short by options.MaxResults, or an empty string once all blobs have been
+// listed. MaxResults counts listed blobs before filtering.
+func (client *AzBlobstore) List(prefix string, filter ListFilter, options ListOptions, handlePage func(items []*BlobItem) error) (string, error) {
+	if err := filter.Validate(); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	return client.storageClient.List(filter.Prefix(prefix), options, func(items []*BlobItem) error {
+		matching := make([]*BlobItem, 0, len(items))
+		for _, item := range items {
+			if filter.Match(item, now) {
+				matching = append(matching, item)
+			}
+		}
+		return handlePage(matching)
+	})
 }
 
-func (client *AzBlobstore) Copy(srcBlob string, dstBlob string) error {
+func (client *AzBlobstore) Copy(ctx context.Context, srcBlob string, dstBlob string, options CopyOptions) error {
+	if err := options.validate(); err != nil {
+		return err
+	}
+
+	return client.storageClient.Copy(ctx, srcBlob, dstBlob, options)
+}
 
-	return client.storageClient.Copy(srcBlob, dstBlob)
+// CopyFrom copies srcBlob of the blobstore source, which may use another
+// container or storage account, to dstBlob on the service side.
+func (client *AzBlobstore) CopyFrom(ctx context.Context, source *AzBlobstore, srcBlob string, dstBlob string, options CopyOptions) error {
+	if err := options.validate(); err != nil {
+		return err
+	}
+
+	srcURL, err := source.storageClient.CopySourceURL(srcBlob, copySourceExpiration)
+	if err != nil {
+		return fmt.Errorf("failed to sign copy source %s: %w", srcBlob, err)
+	}
+
+	return client.storageClient.CopyFromURL(ctx, srcURL, dstBlob, options)
+}
+
+// CopyFromURL copies the blob at srcURL to dstBlob on the service side.
+// srcURL has to be readable by the service, so it is either public or
+// carries a SAS token.
+func (client *AzBlobstore) CopyFromURL(ctx context.Context, srcURL string, dstBlob string, options CopyOptions) error {
+	if err := options.validate(); err != nil {
+		return err
+	}
+
+	parsed, err := url.Parse(srcURL)
+	if err != nil {
+		return fmt.Errorf("invalid source URL: %w", err)
+	}
+	if parsed.Scheme != "https" || parsed.Host == "" {
+		return fmt.Errorf("invalid source URL, expected an https:// URL")
+	}
+
+	return client.storageClient.CopyFromURL(ctx, srcURL, dstBlob, options)
+}
+
+// validate normalizes the tier to the spelling of the service and rejects
+// unknown tiers before anything is copied.
+func (options *CopyOptions) validate() error {
+	if options.Tier == "" {
+		return nil
+	}
+	for _, tier := range azBlob.PossibleAccessTierValues() {
+		if strings.EqualFold(options.Tier, string(tier)) {
+			options.Tier = string(tier)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown access tier '%s'", options.Tier)
 }
 
 func (client *AzBlobstore) Properties(dest string) error {
@@ -1,10 +1,20 @@
 package client_test
 
 import (
+	"bytes"
+	"context"
+	"crypto/md5"
 	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"runtime"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	azContainer "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+
 	"github.com/cloudfoundry/bosh-azure-storage-cli/client"
 	"github.com/cloudfoundry/bosh-azure-storage-cli/client/clientfakes"
 
@@ -23,22 +33,60 @@ var _ = Describe("Client", func() {
 
 			file, _ := os.CreateTemp("", "tmpfile") //nolint:errcheck
 
-			azBlobstore.Put(file.Name(), "target/blob") //nolint:errcheck
+			azBlobstore.Put(file.Name(), "target/blob", client.UploadOptions{}) //nolint:errcheck
 
 			Expect(storageClient.UploadCallCount()).To(Equal(1))
-			source, dest := storageClient.UploadArgsForCall(0)
+			_, dest, _ := storageClient.UploadArgsForCall(0)
 
-			Expect(source).To(BeAssignableToTypeOf((*os.File)(nil)))
 			Expect(dest).To(Equal("target/blob"))
 		})
 
+		It("computes the md5 of the file while it is uploaded", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.UploadStub = func(source io.ReadSeekCloser, _ string, _ client.UploadOptions) ([]byte, error) {
+				// A retried request reads the file again from the start.
+				_, err := io.CopyN(io.Discard, source, 3)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = source.Seek(0, io.SeekStart)
+				Expect(err).ToNot(HaveOccurred())
+				_, err = io.Copy(io.Discard, source)
+				Expect(err).ToNot(HaveOccurred())
+
+				sum := md5.Sum([]byte("content"))
+				return sum[:], nil
+			}
+
+			azBlobstore, err := client.New(&storageClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			path := filepath.Join(GinkgoT().TempDir(), "file")
+			Expect(os.WriteFile(path, []byte("content"), 0o644)).To(Succeed())
+
+			err = azBlobstore.Put(path, "target/blob", client.UploadOptions{})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("passes the lease ID to the upload", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+
+			azBlobstore, err := client.New(&storageClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			file, _ := os.CreateTemp("", "tmpfile") //nolint:errcheck
+
+			azBlobstore.Put(file.Name(), "target/blob", client.UploadOptions{LeaseID: "some-lease"}) //nolint:errcheck
+
+			_, _, options := storageClient.UploadArgsForCall(0)
+			Expect(options.LeaseID).To(Equal("some-lease"))
+		})
+
 		It("skips the upload if the md5 cannot be calculated from the file", func() {
 			storageClient := clientfakes.FakeStorageClient{}
 
 			azBlobstore, err := client.New(&storageClient)
 			Expect(err).ToNot(HaveOccurred())
 
-			err = azBlobstore.Put("the/path", "target/blob")
+			err = azBlobstore.Put("the/path", "target/blob", client.UploadOptions{})
 
 			Expect(storageClient.UploadCallCount()).To(Equal(0))
 			var expectedError string
@@ -50,6 +98,42 @@ var _ = Describe("Client", func() {
 			Expect(err.Error()).To(Equal(expectedError))
 		})
 
+		It("does not check an md5 for uploads in blocks", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.UploadReturns(nil, nil)
+
+			azBlobstore, err := client.New(&storageClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			path := filepath.Join(GinkgoT().TempDir(), "file")
+			Expect(os.WriteFile(path, []byte("content"), 0o644)).To(Succeed())
+
+			err = azBlobstore.Put(path, "target/blob", client.UploadOptions{CRC64: true})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, options := storageClient.UploadArgsForCall(0)
+			Expect(options.CRC64).To(BeTrue())
+			Expect(storageClient.DeleteCallCount()).To(Equal(0))
+		})
+
+		It("does not use md5 when the upload stores a sha256", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.UploadReturns(nil, nil)
+
+			azBlobstore, err := client.New(&storageClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			path := filepath.Join(GinkgoT().TempDir(), "file")
+			Expect(os.WriteFile(path, []byte("content"), 0o644)).To(Succeed())
+
+			err = azBlobstore.Put(path, "target/blob", client.UploadOptions{SHA256: true})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, options := storageClient.UploadArgsForCall(0)
+			Expect(options.SHA256).To(BeTrue())
+			Expect(storageClient.DeleteCallCount()).To(Equal(0))
+		})
+
 		It("fails if the source file md5 does not match the responded md5", func() {
 			storageClient := clientfakes.FakeStorageClient{}
 			storageClient.UploadReturns([]byte{1, 2, 3}, nil)
@@ -59,17 +143,151 @@ var _ = Describe("Client", func() {
 
 			file, _ := os.CreateTemp("", "tmpfile") //nolint:errcheck
 
-			putError := azBlobstore.Put(file.Name(), "target/blob")
+			putError := azBlobstore.Put(file.Name(), "target/blob", client.UploadOptions{LeaseID: "some-lease"})
 			Expect(putError.Error()).To(Equal("the upload responded an MD5 [1 2 3] does not match the source file MD5 [212 29 140 217 143 0 178 4 233 128 9 152 236 248 66 126]"))
 
 			Expect(storageClient.UploadCallCount()).To(Equal(1))
-			source, dest := storageClient.UploadArgsForCall(0)
-			Expect(source).To(BeAssignableToTypeOf((*os.File)(nil)))
+			_, dest, _ := storageClient.UploadArgsForCall(0)
 			Expect(dest).To(Equal("target/blob"))
 
 			Expect(storageClient.DeleteCallCount()).To(Equal(1))
-			dest = storageClient.DeleteArgsForCall(0)
+			dest, deleteOptions := storageClient.DeleteArgsForCall(0)
 			Expect(dest).To(Equal("target/blob"))
+			Expect(deleteOptions.LeaseID).To(Equal("some-lease"))
+		})
+	})
+
+	Context("copy", func() {
+		It("copies from another blobstore through a signed source URL", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			sourceStorageClient := clientfakes.FakeStorageClient{}
+			sourceStorageClient.CopySourceURLReturns("https://staging.blob.core.windows.net/c/some/blob?sig=x", nil)
+
+			azBlobstore, err := client.New(&storageClient)
+			Expect(err).ToNot(HaveOccurred())
+			source, err := client.New(&sourceStorageClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(azBlobstore.CopyFrom(context.Background(), &source, "some/blob", "target/blob", client.CopyOptions{})).To(Succeed())
+
+			name, expiration := sourceStorageClient.CopySourceURLArgsForCall(0)
+			Expect(name).To(Equal("some/blob"))
+			Expect(expiration).To(BeNumerically(">", 0))
+
+			_, srcURL, dest, _ := storageClient.CopyFromURLArgsForCall(0)
+			Expect(srcURL).To(Equal("https://staging.blob.core.windows.net/c/some/blob?sig=x"))
+			Expect(dest).To(Equal("target/blob"))
+			Expect(sourceStorageClient.CopyFromURLCallCount()).To(Equal(0))
+		})
+
+		It("copies from an https URL", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+
+			azBlobstore, err := client.New(&storageClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(azBlobstore.CopyFromURL(context.Background(), "https://example.com/some/blob", "target/blob", client.CopyOptions{})).To(Succeed())
+			_, srcURL, _, _ := storageClient.CopyFromURLArgsForCall(0)
+			Expect(srcURL).To(Equal("https://example.com/some/blob"))
+		})
+
+		It("rejects source URLs which are not https", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+
+			azBlobstore, err := client.New(&storageClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = azBlobstore.CopyFromURL(context.Background(), "http://example.com/some/blob", "target/blob", client.CopyOptions{})
+			Expect(err).To(MatchError("invalid source URL, expected an https:// URL"))
+			Expect(storageClient.CopyFromURLCallCount()).To(Equal(0))
+		})
+
+		It("passes the destination properties to the copy", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+
+			azBlobstore, err := client.New(&storageClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			options := client.CopyOptions{
+				Metadata:  map[string]string{"release": "v1"},
+				Tags:      map[string]string{"stage": "production"},
+				Tier:      "cool",
+				Headers:   client.ContentHeaders{ContentType: "application/gzip"},
+				VerifyMD5: true,
+			}
+			Expect(azBlobstore.Copy(context.Background(), "some/blob", "target/blob", options)).To(Succeed())
+
+			_, src, dest, copyOptions := storageClient.CopyArgsForCall(0)
+			Expect(src).To(Equal("some/blob"))
+			Expect(dest).To(Equal("target/blob"))
+			Expect(copyOptions.Tier).To(Equal("Cool"))
+			Expect(copyOptions.Metadata).To(Equal(options.Metadata))
+			Expect(copyOptions.Tags).To(Equal(options.Tags))
+			Expect(copyOptions.Headers.ContentType).To(Equal("application/gzip"))
+			Expect(copyOptions.VerifyMD5).To(BeTrue())
+		})
+
+		It("rejects unknown access tiers", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+
+			azBlobstore, err := client.New(&storageClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = azBlobstore.Copy(context.Background(), "some/blob", "target/blob", client.CopyOptions{Tier: "Lukewarm"})
+			Expect(err).To(MatchError("unknown access tier 'Lukewarm'"))
+			Expect(storageClient.CopyCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("leases", func() {
+		It("acquires a lease with the given duration and proposed ID", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.AcquireLeaseReturns("some-lease", nil)
+
+			azBlobstore, err := client.New(&storageClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			leaseID, err := azBlobstore.AcquireLease("target/blob", client.InfiniteLease, "proposed")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(leaseID).To(Equal("some-lease"))
+
+			dest, duration, proposedID := storageClient.AcquireLeaseArgsForCall(0)
+			Expect(dest).To(Equal("target/blob"))
+			Expect(duration).To(Equal(int32(-1)))
+			Expect(proposedID).To(Equal("proposed"))
+		})
+
+		It("rejects lease durations the service does not support", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+
+			azBlobstore, err := client.New(&storageClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = azBlobstore.AcquireLease("target/blob", 10, "")
+			Expect(err).To(MatchError("invalid lease duration 10, expected 15 to 60 seconds or infinite"))
+			Expect(storageClient.AcquireLeaseCallCount()).To(Equal(0))
+		})
+
+		It("rejects break periods longer than a minute", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+
+			azBlobstore, err := client.New(&storageClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = azBlobstore.BreakLease("target/blob", 61)
+			Expect(err).To(MatchError("invalid break period 61, expected 0 to 60 seconds"))
+			Expect(storageClient.BreakLeaseCallCount()).To(Equal(0))
+		})
+
+		It("hints at the lease ID when deleting a leased blob", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.DeleteReturns(&azcore.ResponseError{ErrorCode: string(bloberror.LeaseIDMissing), StatusCode: 412})
+
+			azBlobstore, err := client.New(&storageClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = azBlobstore.Delete("target/blob", client.DeleteOptions{})
+			Expect(err).To(MatchError(ContainSubstring("blob target/blob has an active lease, its lease ID is required to delete it")))
 		})
 	})
 
@@ -81,13 +299,14 @@ var _ = Describe("Client", func() {
 
 		file, _ := os.CreateTemp("", "tmpfile") //nolint:errcheck
 
-		azBlobstore.Get("source/blob", file) //nolint:errcheck
+		azBlobstore.Get("source/blob", file, client.DownloadOptions{Snapshot: "2024-01-01T00:00:00.0000000Z"}) //nolint:errcheck
 
 		Expect(storageClient.DownloadCallCount()).To(Equal(1))
-		source, dest := storageClient.DownloadArgsForCall(0)
+		source, dest, options := storageClient.DownloadArgsForCall(0)
 
 		Expect(source).To(Equal("source/blob"))
 		Expect(dest).To(Equal(file))
+		Expect(options.Snapshot).To(Equal("2024-01-01T00:00:00.0000000Z"))
 	})
 
 	It("delete blob deletes the blob", func() {
@@ -96,12 +315,341 @@ var _ = Describe("Client", func() {
 		azBlobstore, err := client.New(&storageClient)
 		Expect(err).ToNot(HaveOccurred())
 
-		azBlobstore.Delete("blob") //nolint:errcheck
+		azBlobstore.Delete("blob", client.DeleteOptions{Snapshots: "include"}) //nolint:errcheck
 
 		Expect(storageClient.DeleteCallCount()).To(Equal(1))
-		dest := storageClient.DeleteArgsForCall(0)
+		dest, options := storageClient.DeleteArgsForCall(0)
 
 		Expect(dest).To(Equal("blob"))
+		Expect(options.Snapshots).To(Equal("include"))
+	})
+
+	It("delete explains how to delete a blob which has snapshots", func() {
+		storageClient := clientfakes.FakeStorageClient{}
+		storageClient.DeleteReturns(&azcore.ResponseError{ErrorCode: "SnapshotsPresent", StatusCode: 409})
+
+		azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+		err := azBlobstore.Delete("blob", client.DeleteOptions{})
+		Expect(err).To(MatchError(ContainSubstring("blob blob has snapshots")))
+	})
+
+	Context("snapshots", func() {
+		It("creates a snapshot and returns its timestamp", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.CreateSnapshotReturns("2024-01-01T00:00:00.0000000Z", nil)
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			snapshot, err := azBlobstore.Snapshot("blob")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(snapshot).To(Equal("2024-01-01T00:00:00.0000000Z"))
+			Expect(storageClient.CreateSnapshotArgsForCall(0)).To(Equal("blob"))
+		})
+
+		It("promotes a snapshot onto its blob", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			err := azBlobstore.PromoteSnapshot(context.Background(), "blob", "2024-01-01T00:00:00.0000000Z")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, dest, snapshot := storageClient.PromoteSnapshotArgsForCall(0)
+			Expect(dest).To(Equal("blob"))
+			Expect(snapshot).To(Equal("2024-01-01T00:00:00.0000000Z"))
+		})
+
+		It("reports only the snapshots a delete of snapshots would delete", func() {
+			snapshot := "2024-01-01T00:00:00.0000000Z"
+			snapshotItem := blobItem("blob")
+			snapshotItem.Snapshot = &snapshot
+
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.ListStub = listPages([]*client.BlobItem{snapshotItem, blobItem("blob")})
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			output := &bytes.Buffer{}
+			summary, err := azBlobstore.DryRunDelete("blob", client.DeleteOptions{Snapshots: "only"}, output)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summary.Count).To(Equal(int64(1)))
+
+			_, options, _ := storageClient.ListArgsForCall(0)
+			Expect(options.Include.Snapshots).To(BeTrue())
+		})
+	})
+
+	Context("delete recursive", func() {
+		It("deletes every listed blob passing the filter in batches", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.ListStub = listPages(
+				[]*client.BlobItem{blobItem("prefix/a"), blobItem("prefix/keep")},
+				[]*client.BlobItem{blobItem("prefix/b")},
+			)
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			err := azBlobstore.DeleteRecursive("prefix/", client.ListFilter{Exclude: []string{"keep"}}, client.DeleteRecursiveOptions{Concurrency: 2})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(storageClient.DeleteBatchCallCount()).To(Equal(2))
+			Expect([][]string{storageClient.DeleteBatchArgsForCall(0), storageClient.DeleteBatchArgsForCall(1)}).To(ConsistOf(
+				[]string{"prefix/a"},
+				[]string{"prefix/b"},
+			))
+			Expect(storageClient.DeleteCallCount()).To(Equal(0))
+		})
+
+		It("splits pages into batches of at most the maximum batch size", func() {
+			var page []*client.BlobItem
+			for i := 0; i < client.MaxBatchSize+1; i++ {
+				page = append(page, blobItem(fmt.Sprintf("blob-%d", i)))
+			}
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.ListStub = listPages(page)
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			err := azBlobstore.DeleteRecursive("", client.ListFilter{}, client.DeleteRecursiveOptions{Concurrency: 1})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(storageClient.DeleteBatchCallCount()).To(Equal(2))
+			Expect(storageClient.DeleteBatchArgsForCall(0)).To(HaveLen(client.MaxBatchSize))
+			Expect(storageClient.DeleteBatchArgsForCall(1)).To(Equal([]string{fmt.Sprintf("blob-%d", client.MaxBatchSize)}))
+		})
+
+		It("falls back to single deletes if the batch request fails", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.ListStub = listPages(
+				[]*client.BlobItem{blobItem("a"), blobItem("b")},
+				[]*client.BlobItem{blobItem("c")},
+			)
+			storageClient.DeleteBatchReturns(nil, &azcore.ResponseError{ErrorCode: "FeatureNotSupported", StatusCode: 409})
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			err := azBlobstore.DeleteRecursive("", client.ListFilter{}, client.DeleteRecursiveOptions{Concurrency: 1})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(storageClient.DeleteBatchCallCount()).To(Equal(1))
+			Expect(storageClient.DeleteCallCount()).To(Equal(3))
+			var deleted []string
+			for i := 0; i < storageClient.DeleteCallCount(); i++ {
+				name, _ := storageClient.DeleteArgsForCall(i)
+				deleted = append(deleted, name)
+			}
+			Expect(deleted).To(ConsistOf("a", "b", "c"))
+		})
+
+		It("reports the blobs of a batch which failed as a whole without falling back", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.ListStub = listPages(
+				[]*client.BlobItem{blobItem("a"), blobItem("b")},
+				[]*client.BlobItem{blobItem("c")},
+			)
+			storageClient.DeleteBatchReturnsOnCall(0, nil, &azcore.ResponseError{ErrorCode: string(bloberror.ServerBusy), StatusCode: 503})
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			err := azBlobstore.DeleteRecursive("", client.ListFilter{}, client.DeleteRecursiveOptions{Concurrency: 1, ContinueOnError: true})
+			Expect(err).To(MatchError(ContainSubstring("failed to delete 2 blobs")))
+			Expect(err).To(MatchError(ContainSubstring("a: 503 ServerBusy")))
+
+			Expect(storageClient.DeleteBatchCallCount()).To(Equal(2))
+			Expect(storageClient.DeleteCallCount()).To(Equal(0))
+		})
+
+		It("reports the blobs which could not be deleted and stops after the first failing batch", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.ListStub = listPages(
+				[]*client.BlobItem{blobItem("leased"), blobItem("b")},
+				[]*client.BlobItem{blobItem("c")},
+				[]*client.BlobItem{blobItem("d")},
+			)
+			storageClient.DeleteBatchReturnsOnCall(0, map[string]error{"leased": errors.New("lease id missing")}, nil)
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			err := azBlobstore.DeleteRecursive("", client.ListFilter{}, client.DeleteRecursiveOptions{Concurrency: 1})
+			Expect(err).To(MatchError("failed to delete 1 blobs:\n  leased: lease id missing"))
+
+			var failures *client.BlobFailures
+			Expect(errors.As(err, &failures)).To(BeTrue())
+			Expect(failures.Errors).To(HaveKey("leased"))
+
+			// The second batch may already be under way when the first one fails.
+			Expect(storageClient.DeleteBatchCallCount()).To(BeNumerically("<", 3))
+		})
+
+		It("keeps deleting after failures when continuing on error", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.ListStub = listPages(
+				[]*client.BlobItem{blobItem("leased"), blobItem("b")},
+				[]*client.BlobItem{blobItem("immutable")},
+			)
+			storageClient.DeleteBatchReturnsOnCall(0, map[string]error{"leased": errors.New("lease id missing")}, nil)
+			storageClient.DeleteBatchReturnsOnCall(1, map[string]error{"immutable": errors.New("immutable")}, nil)
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			err := azBlobstore.DeleteRecursive("", client.ListFilter{}, client.DeleteRecursiveOptions{Concurrency: 1, ContinueOnError: true})
+			Expect(err).To(MatchError("failed to delete 2 blobs:\n  immutable: immutable\n  leased: lease id missing"))
+
+			Expect(storageClient.DeleteBatchCallCount()).To(Equal(2))
+		})
+
+		It("returns an error if listing fails", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.ListReturns("", errors.New("boom"))
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			err := azBlobstore.DeleteRecursive("prefix/", client.ListFilter{}, client.DeleteRecursiveOptions{})
+			Expect(err).To(MatchError("boom"))
+			Expect(storageClient.DeleteBatchCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("dry run", func() {
+		sizedBlobItem := func(name string, size int64) *client.BlobItem {
+			item := blobItem(name)
+			item.Properties = &azContainer.BlobProperties{ContentLength: &size}
+			return item
+		}
+
+		It("reports what delete-recursive would delete without deleting", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.ListStub = listPages(
+				[]*client.BlobItem{sizedBlobItem("prefix/a", 10), sizedBlobItem("prefix/b", 20)},
+				[]*client.BlobItem{sizedBlobItem("prefix/c", 30)},
+			)
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			output := &bytes.Buffer{}
+			summary, err := azBlobstore.DryRunDeleteRecursive("prefix/", client.ListFilter{}, output)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summary).To(Equal(client.DryRunSummary{Count: 3, Bytes: 60}))
+			Expect(output.String()).To(Equal(
+				"would delete prefix/a (10 bytes)\n" +
+					"would delete prefix/b (20 bytes)\n" +
+					"would delete prefix/c (30 bytes)\n" +
+					"would delete 3 blobs (60 bytes)\n",
+			))
+
+			Expect(storageClient.DeleteBatchCallCount()).To(Equal(0))
+			Expect(storageClient.DeleteCallCount()).To(Equal(0))
+		})
+
+		It("reports only the exact blob delete would delete", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.ListStub = listPages([]*client.BlobItem{sizedBlobItem("blob", 5), sizedBlobItem("blob-other", 7)})
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			output := &bytes.Buffer{}
+			summary, err := azBlobstore.DryRunDelete("blob", client.DeleteOptions{}, output)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summary).To(Equal(client.DryRunSummary{Count: 1, Bytes: 5}))
+			Expect(output.String()).To(Equal("would delete blob (5 bytes)\nwould delete 1 blobs (5 bytes)\n"))
+
+			Expect(storageClient.DeleteCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("versions", func() {
+		versionItem := func(name string, versionID string) *client.BlobItem {
+			item := blobItem(name)
+			item.VersionID = &versionID
+			return item
+		}
+
+		It("lists the versions of exactly one blob", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.ListStub = listPages([]*client.BlobItem{
+				versionItem("blob", "v1"), versionItem("blob", "v2"), versionItem("blob-other", "v3"),
+			})
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			versions, err := azBlobstore.Versions("blob")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(versions).To(Equal([]*client.BlobItem{versionItem("blob", "v1"), versionItem("blob", "v2")}))
+
+			prefix, options, _ := storageClient.ListArgsForCall(0)
+			Expect(prefix).To(Equal("blob"))
+			Expect(options.Include.Versions).To(BeTrue())
+		})
+
+		It("restores a version onto its blob", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			err := azBlobstore.RestoreVersion(context.Background(), "blob", "v1")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, dest, versionID := storageClient.RestoreVersionArgsForCall(0)
+			Expect(dest).To(Equal("blob"))
+			Expect(versionID).To(Equal("v1"))
+		})
+	})
+
+	Context("undelete", func() {
+		deletedItem := func(name string) *client.BlobItem {
+			item := blobItem(name)
+			deleted := true
+			item.Deleted = &deleted
+			return item
+		}
+
+		It("restores a soft-deleted blob", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.ListStub = listPages([]*client.BlobItem{deletedItem("blob")})
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			err := azBlobstore.Undelete("blob")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, options, _ := storageClient.ListArgsForCall(0)
+			Expect(options.Include.Deleted).To(BeTrue())
+			Expect(storageClient.UndeleteCallCount()).To(Equal(1))
+			Expect(storageClient.UndeleteArgsForCall(0)).To(Equal("blob"))
+		})
+
+		It("fails if there is no soft-deleted blob of that name", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.ListStub = listPages([]*client.BlobItem{blobItem("blob"), deletedItem("blob-other")})
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			err := azBlobstore.Undelete("blob")
+			Expect(err).To(MatchError("no soft-deleted blob blob found"))
+			Expect(storageClient.UndeleteCallCount()).To(Equal(0))
+		})
+
+		It("restores all soft-deleted blobs below a prefix and reports failures", func() {
+			snapshot := "2024-01-01T00:00:00.0000000Z"
+			deletedSnapshot := deletedItem("prefix/a")
+			deletedSnapshot.Snapshot = &snapshot
+
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.ListStub = listPages([]*client.BlobItem{
+				deletedItem("prefix/a"), deletedSnapshot, blobItem("prefix/live"), deletedItem("prefix/b"),
+			})
+			storageClient.UndeleteStub = func(name string) error {
+				if name == "prefix/b" {
+					return errors.New("boom")
+				}
+				return nil
+			}
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			err := azBlobstore.UndeleteRecursive("prefix/", client.ListFilter{})
+			Expect(err).To(MatchError("failed to undelete 1 blobs:\n  prefix/b: boom"))
+
+			Expect(storageClient.UndeleteCallCount()).To(Equal(2))
+			Expect([]string{storageClient.UndeleteArgsForCall(0), storageClient.UndeleteArgsForCall(1)}).To(ConsistOf("prefix/a", "prefix/b"))
+		})
+
+		It("reports what undelete-recursive would restore without restoring", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.ListStub = listPages([]*client.BlobItem{deletedItem("prefix/a"), blobItem("prefix/live")})
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			output := &bytes.Buffer{}
+			summary, err := azBlobstore.DryRunUndeleteRecursive("prefix/", client.ListFilter{}, output)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summary.Count).To(Equal(int64(1)))
+			Expect(output.String()).To(ContainSubstring("would undelete prefix/a"))
+
+			Expect(storageClient.UndeleteCallCount()).To(Equal(0))
+		})
 	})
 
 	Context("if the blob existence is checked", func() {
@@ -175,44 +723,131 @@ var _ = Describe("Client", func() {
 	})
 
 	Context("list", func() {
-		It("lists blobs in a container", func() {
+		It("streams the blobs of a container page by page", func() {
 			storageClient := clientfakes.FakeStorageClient{}
-			storageClient.ListReturns([]string{"blob1", "blob2"}, nil)
+			storageClient.ListStub = listPages(
+				[]*client.BlobItem{blobItem("blob1"), blobItem("blob2")},
+				[]*client.BlobItem{blobItem("blob3")},
+			)
 
 			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
-			blobs, err := azBlobstore.List("")
-			Expect(blobs).To(Equal([]string{"blob1", "blob2"}))
+			var pages [][]*client.BlobItem
+			token, err := azBlobstore.List("", client.ListFilter{}, client.ListOptions{}, func(items []*client.BlobItem) error {
+				pages = append(pages, items)
+				return nil
+			})
 			Expect(err).ToNot(HaveOccurred())
-
-			containerName := storageClient.ListArgsForCall(0)
-			Expect(containerName).To(Equal(""))
+			Expect(token).To(BeEmpty())
+			Expect(pages).To(Equal([][]*client.BlobItem{
+				{blobItem("blob1"), blobItem("blob2")},
+				{blobItem("blob3")},
+			}))
+
+			prefix, _, _ := storageClient.ListArgsForCall(0)
+			Expect(prefix).To(Equal(""))
 		})
 
 		It("lists blobs with a prefix in a container", func() {
 			storageClient := clientfakes.FakeStorageClient{}
-			storageClient.ListReturns([]string{"pre-blob1", "pre-blob2"}, nil)
+			storageClient.ListStub = listPages([]*client.BlobItem{blobItem("pre-blob1"), blobItem("pre-blob2")})
 
 			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
-			blobs, err := azBlobstore.List("pre-")
+			blobs, err := listNames(azBlobstore, "pre-")
 			Expect(blobs).To(Equal([]string{"pre-blob1", "pre-blob2"}))
 			Expect(err).ToNot(HaveOccurred())
 
-			containerName := storageClient.ListArgsForCall(0)
-			Expect(containerName).To(Equal("pre-"))
+			prefix, _, _ := storageClient.ListArgsForCall(0)
+			Expect(prefix).To(Equal("pre-"))
+		})
+
+		It("passes max results and continuation token through and returns the next token", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.ListReturns("next-token", nil)
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			token, err := azBlobstore.List("", client.ListFilter{}, client.ListOptions{MaxResults: 10, Marker: "token"}, func([]*client.BlobItem) error {
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(token).To(Equal("next-token"))
+
+			_, options, _ := storageClient.ListArgsForCall(0)
+			Expect(options).To(Equal(client.ListOptions{MaxResults: 10, Marker: "token"}))
+		})
+
+		It("only hands on blobs passing the filter and narrows the prefix", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+			storageClient.ListStub = listPages([]*client.BlobItem{
+				blobItem("pre-packages/a.tgz"), blobItem("pre-packages/b.txt"), blobItem("pre-packages/c.tgz"),
+			})
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			var names []string
+			_, err := azBlobstore.List("pre-", client.ListFilter{
+				Include: []string{"pre-packages/*.tgz"},
+				Exclude: []string{"*/c.*"},
+			}, client.ListOptions{}, func(items []*client.BlobItem) error {
+				for _, item := range items {
+					names = append(names, *item.Name)
+				}
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names).To(Equal([]string{"pre-packages/a.tgz"}))
+
+			prefix, _, _ := storageClient.ListArgsForCall(0)
+			Expect(prefix).To(Equal("pre-packages/"))
+		})
+
+		It("rejects invalid filters before listing", func() {
+			storageClient := clientfakes.FakeStorageClient{}
+
+			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
+			_, err := azBlobstore.List("", client.ListFilter{Include: []string{"["}}, client.ListOptions{}, func([]*client.BlobItem) error {
+				return nil
+			})
+			Expect(err).To(MatchError(ContainSubstring("invalid pattern '['")))
+			Expect(storageClient.ListCallCount()).To(Equal(0))
 		})
 
 		It("returns an error if listing fails", func() {
 			storageClient := clientfakes.FakeStorageClient{}
-			storageClient.ListReturns(nil, errors.New("boom"))
+			storageClient.ListReturns("", errors.New("boom"))
 
 			azBlobstore, _ := client.New(&storageClient) //nolint:errcheck
-			blobs, err := azBlobstore.List("container")
+			blobs, err := listNames(azBlobstore, "container")
 			Expect(blobs).To(BeNil())
 			Expect(err).To(HaveOccurred())
 
-			containerName := storageClient.ListArgsForCall(0)
-			Expect(containerName).To(Equal("container"))
+			prefix, _, _ := storageClient.ListArgsForCall(0)
+			Expect(prefix).To(Equal("container"))
 		})
 	})
 
 })
+
+func blobItem(name string) *client.BlobItem {
+	return &client.BlobItem{Name: &name}
+}
+
+func listPages(pages ...[]*client.BlobItem) func(string, client.ListOptions, func([]*client.BlobItem) error) (string, error) {
+	return func(_ string, _ client.ListOptions, handlePage func([]*client.BlobItem) error) (string, error) {
+		for _, page := range pages {
+			if err := handlePage(page); err != nil {
+				return "", err
+			}
+		}
+		return "", nil
+	}
+}
+
+func listNames(azBlobstore client.AzBlobstore, prefix string) ([]string, error) {
+	var names []string
+	_, err := azBlobstore.List(prefix, client.ListFilter{}, client.ListOptions{}, func(items []*client.BlobItem) error {
+		for _, item := range items {
+			names = append(names, *item.Name)
+		}
+		return nil
+	})
+	return names, err
+}
@@ -2,6 +2,7 @@
 package clientfakes
 
 import (
+	"context"
 	"io"
 	"os"
 	"sync"
@@ -11,11 +12,67 @@ import (
 )
 
 type FakeStorageClient struct {
-	CopyStub        func(string, string) error
+	AcquireLeaseStub        func(string, int32, string) (string, error)
+	acquireLeaseMutex       sync.RWMutex
+	acquireLeaseArgsForCall []struct {
+		arg1 string
+		arg2 int32
+		arg3 string
+	}
+	acquireLeaseReturns struct {
+		result1 string
+		result2 error
+	}
+	acquireLeaseReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	BreakLeaseStub        func(string, int32) (int32, error)
+	breakLeaseMutex       sync.RWMutex
+	breakLeaseArgsForCall []struct {
+		arg1 string
+		arg2 int32
+	}
+	breakLeaseReturns struct {
+		result1 int32
+		result2 error
+	}
+	breakLeaseReturnsOnCall map[int]struct {
+		result1 int32
+		result2 error
+	}
+	ChangeLeaseStub        func(string, string, string) (string, error)
+	changeLeaseMutex       sync.RWMutex
+	changeLeaseArgsForCall []struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}
+	changeLeaseReturns struct {
+		result1 string
+		result2 error
+	}
+	changeLeaseReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	ContainerURLStub        func() string
+	containerURLMutex       sync.RWMutex
+	containerURLArgsForCall []struct {
+	}
+	containerURLReturns struct {
+		result1 string
+	}
+	containerURLReturnsOnCall map[int]struct {
+		result1 string
+	}
+	CopyStub        func(context.Context, string, string, client.CopyOptions) error
 	copyMutex       sync.RWMutex
 	copyArgsForCall []struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
+		arg3 string
+		arg4 client.CopyOptions
 	}
 	copyReturns struct {
 		result1 error
@@ -23,10 +80,52 @@ type FakeStorageClient struct {
 	copyReturnsOnCall map[int]struct {
 		result1 error
 	}
-	DeleteStub        func(string) error
+	CopyFromURLStub        func(context.Context, string, string, client.CopyOptions) error
+	copyFromURLMutex       sync.RWMutex
+	copyFromURLArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 client.CopyOptions
+	}
+	copyFromURLReturns struct {
+		result1 error
+	}
+	copyFromURLReturnsOnCall map[int]struct {
+		result1 error
+	}
+	CopySourceURLStub        func(string, time.Duration) (string, error)
+	copySourceURLMutex       sync.RWMutex
+	copySourceURLArgsForCall []struct {
+		arg1 string
+		arg2 time.Duration
+	}
+	copySourceURLReturns struct {
+		result1 string
+		result2 error
+	}
+	copySourceURLReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	CreateSnapshotStub        func(string) (string, error)
+	createSnapshotMutex       sync.RWMutex
+	createSnapshotArgsForCall []struct {
+		arg1 string
+	}
+	createSnapshotReturns struct {
+		result1 string
+		result2 error
+	}
+	createSnapshotReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	DeleteStub        func(string, client.DeleteOptions) error
 	deleteMutex       sync.RWMutex
 	deleteArgsForCall []struct {
 		arg1 string
+		arg2 client.DeleteOptions
 	}
 	deleteReturns struct {
 		result1 error
@@ -34,22 +133,25 @@ type FakeStorageClient struct {
 	deleteReturnsOnCall map[int]struct {
 		result1 error
 	}
-	DeleteRecursiveStub        func(string) error
-	deleteRecursiveMutex       sync.RWMutex
-	deleteRecursiveArgsForCall []struct {
-		arg1 string
+	DeleteBatchStub        func([]string) (map[string]error, error)
+	deleteBatchMutex       sync.RWMutex
+	deleteBatchArgsForCall []struct {
+		arg1 []string
 	}
-	deleteRecursiveReturns struct {
-		result1 error
+	deleteBatchReturns struct {
+		result1 map[string]error
+		result2 error
 	}
-	deleteRecursiveReturnsOnCall map[int]struct {
-		result1 error
+	deleteBatchReturnsOnCall map[int]struct {
+		result1 map[string]error
+		result2 error
 	}
-	DownloadStub        func(string, *os.File) error
+	DownloadStub        func(string, *os.File, client.DownloadOptions) error
 	downloadMutex       sync.RWMutex
 	downloadArgsForCall []struct {
 		arg1 string
 		arg2 *os.File
+		arg3 client.DownloadOptions
 	}
 	downloadReturns struct {
 		result1 error
@@ -57,6 +159,19 @@ type FakeStorageClient struct {
 	downloadReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DownloadStreamStub        func(string) (io.ReadCloser, error)
+	downloadStreamMutex       sync.RWMutex
+	downloadStreamArgsForCall []struct {
+		arg1 string
+	}
+	downloadStreamReturns struct {
+		result1 io.ReadCloser
+		result2 error
+	}
+	downloadStreamReturnsOnCall map[int]struct {
+		result1 io.ReadCloser
+		result2 error
+	}
 	EnsureContainerExistsStub        func() error
 	ensureContainerExistsMutex       sync.RWMutex
 	ensureContainerExistsArgsForCall []struct {
@@ -80,19 +195,47 @@ type FakeStorageClient struct {
 		result1 bool
 		result2 error
 	}
-	ListStub        func(string) ([]string, error)
+	GetPropertiesStub        func(string) (client.BlobProperties, error)
+	getPropertiesMutex       sync.RWMutex
+	getPropertiesArgsForCall []struct {
+		arg1 string
+	}
+	getPropertiesReturns struct {
+		result1 client.BlobProperties
+		result2 error
+	}
+	getPropertiesReturnsOnCall map[int]struct {
+		result1 client.BlobProperties
+		result2 error
+	}
+	ListStub        func(string, client.ListOptions, func(items []*client.BlobItem) error) (string, error)
 	listMutex       sync.RWMutex
 	listArgsForCall []struct {
 		arg1 string
+		arg2 client.ListOptions
+		arg3 func(items []*client.BlobItem) error
 	}
 	listReturns struct {
-		result1 []string
+		result1 string
 		result2 error
 	}
 	listReturnsOnCall map[int]struct {
-		result1 []string
+		result1 string
 		result2 error
 	}
+	PromoteSnapshotStub        func(context.Context, string, string) error
+	promoteSnapshotMutex       sync.RWMutex
+	promoteSnapshotArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	promoteSnapshotReturns struct {
+		result1 error
+	}
+	promoteSnapshotReturnsOnCall map[int]struct {
+		result1 error
+	}
 	PropertiesStub        func(string) error
 	propertiesMutex       sync.RWMutex
 	propertiesArgsForCall []struct {
@@ -104,6 +247,44 @@ type FakeStorageClient struct {
 	propertiesReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ReleaseLeaseStub        func(string, string) error
+	releaseLeaseMutex       sync.RWMutex
+	releaseLeaseArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	releaseLeaseReturns struct {
+		result1 error
+	}
+	releaseLeaseReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RenewLeaseStub        func(context.Context, string, string) error
+	renewLeaseMutex       sync.RWMutex
+	renewLeaseArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	renewLeaseReturns struct {
+		result1 error
+	}
+	renewLeaseReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RestoreVersionStub        func(context.Context, string, string) error
+	restoreVersionMutex       sync.RWMutex
+	restoreVersionArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}
+	restoreVersionReturns struct {
+		result1 error
+	}
+	restoreVersionReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SignedUrlStub        func(string, string, time.Duration) (string, error)
 	signedUrlMutex       sync.RWMutex
 	signedUrlArgsForCall []struct {
@@ -119,11 +300,23 @@ type FakeStorageClient struct {
 		result1 string
 		result2 error
 	}
-	UploadStub        func(io.ReadSeekCloser, string) ([]byte, error)
+	UndeleteStub        func(string) error
+	undeleteMutex       sync.RWMutex
+	undeleteArgsForCall []struct {
+		arg1 string
+	}
+	undeleteReturns struct {
+		result1 error
+	}
+	undeleteReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UploadStub        func(io.ReadSeekCloser, string, client.UploadOptions) ([]byte, error)
 	uploadMutex       sync.RWMutex
 	uploadArgsForCall []struct {
 		arg1 io.ReadSeekCloser
 		arg2 string
+		arg3 client.UploadOptions
 	}
 	uploadReturns struct {
 		result1 []byte
@@ -137,19 +330,271 @@ type FakeStorageClient struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *FakeStorageClient) Copy(arg1 string, arg2 string) error {
+func (fake *FakeStorageClient) AcquireLease(arg1 string, arg2 int32, arg3 string) (string, error) {
+	fake.acquireLeaseMutex.Lock()
+	ret, specificReturn := fake.acquireLeaseReturnsOnCall[len(fake.acquireLeaseArgsForCall)]
+	fake.acquireLeaseArgsForCall = append(fake.acquireLeaseArgsForCall, struct {
+		arg1 string
+		arg2 int32
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.AcquireLeaseStub
+	fakeReturns := fake.acquireLeaseReturns
+	fake.recordInvocation("AcquireLease", []interface{}{arg1, arg2, arg3})
+	fake.acquireLeaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeStorageClient) AcquireLeaseCallCount() int {
+	fake.acquireLeaseMutex.RLock()
+	defer fake.acquireLeaseMutex.RUnlock()
+	return len(fake.acquireLeaseArgsForCall)
+}
+
+func (fake *FakeStorageClient) AcquireLeaseCalls(stub func(string, int32, string) (string, error)) {
+	fake.acquireLeaseMutex.Lock()
+	defer fake.acquireLeaseMutex.Unlock()
+	fake.AcquireLeaseStub = stub
+}
+
+func (fake *FakeStorageClient) AcquireLeaseArgsForCall(i int) (string, int32, string) {
+	fake.acquireLeaseMutex.RLock()
+	defer fake.acquireLeaseMutex.RUnlock()
+	argsForCall := fake.acquireLeaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeStorageClient) AcquireLeaseReturns(result1 string, result2 error) {
+	fake.acquireLeaseMutex.Lock()
+	defer fake.acquireLeaseMutex.Unlock()
+	fake.AcquireLeaseStub = nil
+	fake.acquireLeaseReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStorageClient) AcquireLeaseReturnsOnCall(i int, result1 string, result2 error) {
+	fake.acquireLeaseMutex.Lock()
+	defer fake.acquireLeaseMutex.Unlock()
+	fake.AcquireLeaseStub = nil
+	if fake.acquireLeaseReturnsOnCall == nil {
+		fake.acquireLeaseReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.acquireLeaseReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStorageClient) BreakLease(arg1 string, arg2 int32) (int32, error) {
+	fake.breakLeaseMutex.Lock()
+	ret, specificReturn := fake.breakLeaseReturnsOnCall[len(fake.breakLeaseArgsForCall)]
+	fake.breakLeaseArgsForCall = append(fake.breakLeaseArgsForCall, struct {
+		arg1 string
+		arg2 int32
+	}{arg1, arg2})
+	stub := fake.BreakLeaseStub
+	fakeReturns := fake.breakLeaseReturns
+	fake.recordInvocation("BreakLease", []interface{}{arg1, arg2})
+	fake.breakLeaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeStorageClient) BreakLeaseCallCount() int {
+	fake.breakLeaseMutex.RLock()
+	defer fake.breakLeaseMutex.RUnlock()
+	return len(fake.breakLeaseArgsForCall)
+}
+
+func (fake *FakeStorageClient) BreakLeaseCalls(stub func(string, int32) (int32, error)) {
+	fake.breakLeaseMutex.Lock()
+	defer fake.breakLeaseMutex.Unlock()
+	fake.BreakLeaseStub = stub
+}
+
+func (fake *FakeStorageClient) BreakLeaseArgsForCall(i int) (string, int32) {
+	fake.breakLeaseMutex.RLock()
+	defer fake.breakLeaseMutex.RUnlock()
+	argsForCall := fake.breakLeaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeStorageClient) BreakLeaseReturns(result1 int32, result2 error) {
+	fake.breakLeaseMutex.Lock()
+	defer fake.breakLeaseMutex.Unlock()
+	fake.BreakLeaseStub = nil
+	fake.breakLeaseReturns = struct {
+		result1 int32
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStorageClient) BreakLeaseReturnsOnCall(i int, result1 int32, result2 error) {
+	fake.breakLeaseMutex.Lock()
+	defer fake.breakLeaseMutex.Unlock()
+	fake.BreakLeaseStub = nil
+	if fake.breakLeaseReturnsOnCall == nil {
+		fake.breakLeaseReturnsOnCall = make(map[int]struct {
+			result1 int32
+			result2 error
+		})
+	}
+	fake.breakLeaseReturnsOnCall[i] = struct {
+		result1 int32
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStorageClient) ChangeLease(arg1 string, arg2 string, arg3 string) (string, error) {
+	fake.changeLeaseMutex.Lock()
+	ret, specificReturn := fake.changeLeaseReturnsOnCall[len(fake.changeLeaseArgsForCall)]
+	fake.changeLeaseArgsForCall = append(fake.changeLeaseArgsForCall, struct {
+		arg1 string
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.ChangeLeaseStub
+	fakeReturns := fake.changeLeaseReturns
+	fake.recordInvocation("ChangeLease", []interface{}{arg1, arg2, arg3})
+	fake.changeLeaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeStorageClient) ChangeLeaseCallCount() int {
+	fake.changeLeaseMutex.RLock()
+	defer fake.changeLeaseMutex.RUnlock()
+	return len(fake.changeLeaseArgsForCall)
+}
+
+func (fake *FakeStorageClient) ChangeLeaseCalls(stub func(string, string, string) (string, error)) {
+	fake.changeLeaseMutex.Lock()
+	defer fake.changeLeaseMutex.Unlock()
+	fake.ChangeLeaseStub = stub
+}
+
+func (fake *FakeStorageClient) ChangeLeaseArgsForCall(i int) (string, string, string) {
+	fake.changeLeaseMutex.RLock()
+	defer fake.changeLeaseMutex.RUnlock()
+	argsForCall := fake.changeLeaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeStorageClient) ChangeLeaseReturns(result1 string, result2 error) {
+	fake.changeLeaseMutex.Lock()
+	defer fake.changeLeaseMutex.Unlock()
+	fake.ChangeLeaseStub = nil
+	fake.changeLeaseReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStorageClient) ChangeLeaseReturnsOnCall(i int, result1 string, result2 error) {
+	fake.changeLeaseMutex.Lock()
+	defer fake.changeLeaseMutex.Unlock()
+	fake.ChangeLeaseStub = nil
+	if fake.changeLeaseReturnsOnCall == nil {
+		fake.changeLeaseReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.changeLeaseReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStorageClient) ContainerURL() string {
+	fake.containerURLMutex.Lock()
+	ret, specificReturn := fake.containerURLReturnsOnCall[len(fake.containerURLArgsForCall)]
+	fake.containerURLArgsForCall = append(fake.containerURLArgsForCall, struct {
+	}{})
+	stub := fake.ContainerURLStub
+	fakeReturns := fake.containerURLReturns
+	fake.recordInvocation("ContainerURL", []interface{}{})
+	fake.containerURLMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeStorageClient) ContainerURLCallCount() int {
+	fake.containerURLMutex.RLock()
+	defer fake.containerURLMutex.RUnlock()
+	return len(fake.containerURLArgsForCall)
+}
+
+func (fake *FakeStorageClient) ContainerURLCalls(stub func() string) {
+	fake.containerURLMutex.Lock()
+	defer fake.containerURLMutex.Unlock()
+	fake.ContainerURLStub = stub
+}
+
+func (fake *FakeStorageClient) ContainerURLReturns(result1 string) {
+	fake.containerURLMutex.Lock()
+	defer fake.containerURLMutex.Unlock()
+	fake.ContainerURLStub = nil
+	fake.containerURLReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeStorageClient) ContainerURLReturnsOnCall(i int, result1 string) {
+	fake.containerURLMutex.Lock()
+	defer fake.containerURLMutex.Unlock()
+	fake.ContainerURLStub = nil
+	if fake.containerURLReturnsOnCall == nil {
+		fake.containerURLReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.containerURLReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeStorageClient) Copy(arg1 context.Context, arg2 string, arg3 string, arg4 client.CopyOptions) error {
 	fake.copyMutex.Lock()
 	ret, specificReturn := fake.copyReturnsOnCall[len(fake.copyArgsForCall)]
 	fake.copyArgsForCall = append(fake.copyArgsForCall, struct {
-		arg1 string
+		arg1 context.Context
 		arg2 string
-	}{arg1, arg2})
+		arg3 string
+		arg4 client.CopyOptions
+	}{arg1, arg2, arg3, arg4})
 	stub := fake.CopyStub
 	fakeReturns := fake.copyReturns
-	fake.recordInvocation("Copy", []interface{}{arg1, arg2})
+	fake.recordInvocation("Copy", []interface{}{arg1, arg2, arg3, arg4})
 	fake.copyMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1
@@ -163,17 +608,17 @@ func (fake *FakeStorageClient) CopyCallCount() int {
 	return len(fake.copyArgsForCall)
 }
 
-func (fake *FakeStorageClient) CopyCalls(stub func(string, string) error) {
+func (fake *FakeStorageClient) CopyCalls(stub func(context.Context, string, string, client.CopyOptions) error) {
 	fake.copyMutex.Lock()
 	defer fake.copyMutex.Unlock()
 	fake.CopyStub = stub
 }
 
-func (fake *FakeStorageClient) CopyArgsForCall(i int) (string, string) {
+func (fake *FakeStorageClient) CopyArgsForCall(i int) (context.Context, string, string, client.CopyOptions) {
 	fake.copyMutex.RLock()
 	defer fake.copyMutex.RUnlock()
 	argsForCall := fake.copyArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *FakeStorageClient) CopyReturns(result1 error) {
@@ -199,18 +644,212 @@ func (fake *FakeStorageClient) CopyReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeStorageClient) Delete(arg1 string) error {
+func (fake *FakeStorageClient) CopyFromURL(arg1 context.Context, arg2 string, arg3 string, arg4 client.CopyOptions) error {
+	fake.copyFromURLMutex.Lock()
+	ret, specificReturn := fake.copyFromURLReturnsOnCall[len(fake.copyFromURLArgsForCall)]
+	fake.copyFromURLArgsForCall = append(fake.copyFromURLArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+		arg4 client.CopyOptions
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.CopyFromURLStub
+	fakeReturns := fake.copyFromURLReturns
+	fake.recordInvocation("CopyFromURL", []interface{}{arg1, arg2, arg3, arg4})
+	fake.copyFromURLMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeStorageClient) CopyFromURLCallCount() int {
+	fake.copyFromURLMutex.RLock()
+	defer fake.copyFromURLMutex.RUnlock()
+	return len(fake.copyFromURLArgsForCall)
+}
+
+func (fake *FakeStorageClient) CopyFromURLCalls(stub func(context.Context, string, string, client.CopyOptions) error) {
+	fake.copyFromURLMutex.Lock()
+	defer fake.copyFromURLMutex.Unlock()
+	fake.CopyFromURLStub = stub
+}
+
+func (fake *FakeStorageClient) CopyFromURLArgsForCall(i int) (context.Context, string, string, client.CopyOptions) {
+	fake.copyFromURLMutex.RLock()
+	defer fake.copyFromURLMutex.RUnlock()
+	argsForCall := fake.copyFromURLArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeStorageClient) CopyFromURLReturns(result1 error) {
+	fake.copyFromURLMutex.Lock()
+	defer fake.copyFromURLMutex.Unlock()
+	fake.CopyFromURLStub = nil
+	fake.copyFromURLReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) CopyFromURLReturnsOnCall(i int, result1 error) {
+	fake.copyFromURLMutex.Lock()
+	defer fake.copyFromURLMutex.Unlock()
+	fake.CopyFromURLStub = nil
+	if fake.copyFromURLReturnsOnCall == nil {
+		fake.copyFromURLReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.copyFromURLReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) CopySourceURL(arg1 string, arg2 time.Duration) (string, error) {
+	fake.copySourceURLMutex.Lock()
+	ret, specificReturn := fake.copySourceURLReturnsOnCall[len(fake.copySourceURLArgsForCall)]
+	fake.copySourceURLArgsForCall = append(fake.copySourceURLArgsForCall, struct {
+		arg1 string
+		arg2 time.Duration
+	}{arg1, arg2})
+	stub := fake.CopySourceURLStub
+	fakeReturns := fake.copySourceURLReturns
+	fake.recordInvocation("CopySourceURL", []interface{}{arg1, arg2})
+	fake.copySourceURLMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeStorageClient) CopySourceURLCallCount() int {
+	fake.copySourceURLMutex.RLock()
+	defer fake.copySourceURLMutex.RUnlock()
+	return len(fake.copySourceURLArgsForCall)
+}
+
+func (fake *FakeStorageClient) CopySourceURLCalls(stub func(string, time.Duration) (string, error)) {
+	fake.copySourceURLMutex.Lock()
+	defer fake.copySourceURLMutex.Unlock()
+	fake.CopySourceURLStub = stub
+}
+
+func (fake *FakeStorageClient) CopySourceURLArgsForCall(i int) (string, time.Duration) {
+	fake.copySourceURLMutex.RLock()
+	defer fake.copySourceURLMutex.RUnlock()
+	argsForCall := fake.copySourceURLArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeStorageClient) CopySourceURLReturns(result1 string, result2 error) {
+	fake.copySourceURLMutex.Lock()
+	defer fake.copySourceURLMutex.Unlock()
+	fake.CopySourceURLStub = nil
+	fake.copySourceURLReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStorageClient) CopySourceURLReturnsOnCall(i int, result1 string, result2 error) {
+	fake.copySourceURLMutex.Lock()
+	defer fake.copySourceURLMutex.Unlock()
+	fake.CopySourceURLStub = nil
+	if fake.copySourceURLReturnsOnCall == nil {
+		fake.copySourceURLReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.copySourceURLReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStorageClient) CreateSnapshot(arg1 string) (string, error) {
+	fake.createSnapshotMutex.Lock()
+	ret, specificReturn := fake.createSnapshotReturnsOnCall[len(fake.createSnapshotArgsForCall)]
+	fake.createSnapshotArgsForCall = append(fake.createSnapshotArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.CreateSnapshotStub
+	fakeReturns := fake.createSnapshotReturns
+	fake.recordInvocation("CreateSnapshot", []interface{}{arg1})
+	fake.createSnapshotMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeStorageClient) CreateSnapshotCallCount() int {
+	fake.createSnapshotMutex.RLock()
+	defer fake.createSnapshotMutex.RUnlock()
+	return len(fake.createSnapshotArgsForCall)
+}
+
+func (fake *FakeStorageClient) CreateSnapshotCalls(stub func(string) (string, error)) {
+	fake.createSnapshotMutex.Lock()
+	defer fake.createSnapshotMutex.Unlock()
+	fake.CreateSnapshotStub = stub
+}
+
+func (fake *FakeStorageClient) CreateSnapshotArgsForCall(i int) string {
+	fake.createSnapshotMutex.RLock()
+	defer fake.createSnapshotMutex.RUnlock()
+	argsForCall := fake.createSnapshotArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeStorageClient) CreateSnapshotReturns(result1 string, result2 error) {
+	fake.createSnapshotMutex.Lock()
+	defer fake.createSnapshotMutex.Unlock()
+	fake.CreateSnapshotStub = nil
+	fake.createSnapshotReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStorageClient) CreateSnapshotReturnsOnCall(i int, result1 string, result2 error) {
+	fake.createSnapshotMutex.Lock()
+	defer fake.createSnapshotMutex.Unlock()
+	fake.CreateSnapshotStub = nil
+	if fake.createSnapshotReturnsOnCall == nil {
+		fake.createSnapshotReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.createSnapshotReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStorageClient) Delete(arg1 string, arg2 client.DeleteOptions) error {
 	fake.deleteMutex.Lock()
 	ret, specificReturn := fake.deleteReturnsOnCall[len(fake.deleteArgsForCall)]
 	fake.deleteArgsForCall = append(fake.deleteArgsForCall, struct {
 		arg1 string
-	}{arg1})
+		arg2 client.DeleteOptions
+	}{arg1, arg2})
 	stub := fake.DeleteStub
 	fakeReturns := fake.deleteReturns
-	fake.recordInvocation("Delete", []interface{}{arg1})
+	fake.recordInvocation("Delete", []interface{}{arg1, arg2})
 	fake.deleteMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
@@ -224,17 +863,17 @@ func (fake *FakeStorageClient) DeleteCallCount() int {
 	return len(fake.deleteArgsForCall)
 }
 
-func (fake *FakeStorageClient) DeleteCalls(stub func(string) error) {
+func (fake *FakeStorageClient) DeleteCalls(stub func(string, client.DeleteOptions) error) {
 	fake.deleteMutex.Lock()
 	defer fake.deleteMutex.Unlock()
 	fake.DeleteStub = stub
 }
 
-func (fake *FakeStorageClient) DeleteArgsForCall(i int) string {
+func (fake *FakeStorageClient) DeleteArgsForCall(i int) (string, client.DeleteOptions) {
 	fake.deleteMutex.RLock()
 	defer fake.deleteMutex.RUnlock()
 	argsForCall := fake.deleteArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *FakeStorageClient) DeleteReturns(result1 error) {
@@ -260,80 +899,89 @@ func (fake *FakeStorageClient) DeleteReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *FakeStorageClient) DeleteRecursive(arg1 string) error {
-	fake.deleteRecursiveMutex.Lock()
-	ret, specificReturn := fake.deleteRecursiveReturnsOnCall[len(fake.deleteRecursiveArgsForCall)]
-	fake.deleteRecursiveArgsForCall = append(fake.deleteRecursiveArgsForCall, struct {
-		arg1 string
-	}{arg1})
-	stub := fake.DeleteRecursiveStub
-	fakeReturns := fake.deleteRecursiveReturns
-	fake.recordInvocation("DeleteRecursive", []interface{}{arg1})
-	fake.deleteRecursiveMutex.Unlock()
+func (fake *FakeStorageClient) DeleteBatch(arg1 []string) (map[string]error, error) {
+	var arg1Copy []string
+	if arg1 != nil {
+		arg1Copy = make([]string, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.deleteBatchMutex.Lock()
+	ret, specificReturn := fake.deleteBatchReturnsOnCall[len(fake.deleteBatchArgsForCall)]
+	fake.deleteBatchArgsForCall = append(fake.deleteBatchArgsForCall, struct {
+		arg1 []string
+	}{arg1Copy})
+	stub := fake.DeleteBatchStub
+	fakeReturns := fake.deleteBatchReturns
+	fake.recordInvocation("DeleteBatch", []interface{}{arg1Copy})
+	fake.deleteBatchMutex.Unlock()
 	if stub != nil {
 		return stub(arg1)
 	}
 	if specificReturn {
-		return ret.result1
+		return ret.result1, ret.result2
 	}
-	return fakeReturns.result1
+	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *FakeStorageClient) DeleteRecursiveCallCount() int {
-	fake.deleteRecursiveMutex.RLock()
-	defer fake.deleteRecursiveMutex.RUnlock()
-	return len(fake.deleteRecursiveArgsForCall)
+func (fake *FakeStorageClient) DeleteBatchCallCount() int {
+	fake.deleteBatchMutex.RLock()
+	defer fake.deleteBatchMutex.RUnlock()
+	return len(fake.deleteBatchArgsForCall)
 }
 
-func (fake *FakeStorageClient) DeleteRecursiveCalls(stub func(string) error) {
-	fake.deleteRecursiveMutex.Lock()
-	defer fake.deleteRecursiveMutex.Unlock()
-	fake.DeleteRecursiveStub = stub
+func (fake *FakeStorageClient) DeleteBatchCalls(stub func([]string) (map[string]error, error)) {
+	fake.deleteBatchMutex.Lock()
+	defer fake.deleteBatchMutex.Unlock()
+	fake.DeleteBatchStub = stub
 }
 
-func (fake *FakeStorageClient) DeleteRecursiveArgsForCall(i int) string {
-	fake.deleteRecursiveMutex.RLock()
-	defer fake.deleteRecursiveMutex.RUnlock()
-	argsForCall := fake.deleteRecursiveArgsForCall[i]
+func (fake *FakeStorageClient) DeleteBatchArgsForCall(i int) []string {
+	fake.deleteBatchMutex.RLock()
+	defer fake.deleteBatchMutex.RUnlock()
+	argsForCall := fake.deleteBatchArgsForCall[i]
 	return argsForCall.arg1
 }
 
-func (fake *FakeStorageClient) DeleteRecursiveReturns(result1 error) {
-	fake.deleteRecursiveMutex.Lock()
-	defer fake.deleteRecursiveMutex.Unlock()
-	fake.DeleteRecursiveStub = nil
-	fake.deleteRecursiveReturns = struct {
-		result1 error
-	}{result1}
+func (fake *FakeStorageClient) DeleteBatchReturns(result1 map[string]error, result2 error) {
+	fake.deleteBatchMutex.Lock()
+	defer fake.deleteBatchMutex.Unlock()
+	fake.DeleteBatchStub = nil
+	fake.deleteBatchReturns = struct {
+		result1 map[string]error
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *FakeStorageClient) DeleteRecursiveReturnsOnCall(i int, result1 error) {
-	fake.deleteRecursiveMutex.Lock()
-	defer fake.deleteRecursiveMutex.Unlock()
-	fake.DeleteRecursiveStub = nil
-	if fake.deleteRecursiveReturnsOnCall == nil {
-		fake.deleteRecursiveReturnsOnCall = make(map[int]struct {
-			result1 error
+func (fake *FakeStorageClient) DeleteBatchReturnsOnCall(i int, result1 map[string]error, result2 error) {
+	fake.deleteBatchMutex.Lock()
+	defer fake.deleteBatchMutex.Unlock()
+	fake.DeleteBatchStub = nil
+	if fake.deleteBatchReturnsOnCall == nil {
+		fake.deleteBatchReturnsOnCall = make(map[int]struct {
+			result1 map[string]error
+			result2 error
 		})
 	}
-	fake.deleteRecursiveReturnsOnCall[i] = struct {
-		result1 error
-	}{result1}
+	fake.deleteBatchReturnsOnCall[i] = struct {
+		result1 map[string]error
+		result2 error
+	}{result1, result2}
 }
 
-func (fake *FakeStorageClient) Download(arg1 string, arg2 *os.File) error {
+func (fake *FakeStorageClient) Download(arg1 string, arg2 *os.File, arg3 client.DownloadOptions) error {
 	fake.downloadMutex.Lock()
 	ret, specificReturn := fake.downloadReturnsOnCall[len(fake.downloadArgsForCall)]
 	fake.downloadArgsForCall = append(fake.downloadArgsForCall, struct {
 		arg1 string
 		arg2 *os.File
-	}{arg1, arg2})
+		arg3 client.DownloadOptions
+	}{arg1, arg2, arg3})
 	stub := fake.DownloadStub
 	fakeReturns := fake.downloadReturns
-	fake.recordInvocation("Download", []interface{}{arg1, arg2})
+	fake.recordInvocation("Download", []interface{}{arg1, arg2, arg3})
 	fake.downloadMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
@@ -347,17 +995,17 @@ func (fake *FakeStorageClient) DownloadCallCount() int {
 	return len(fake.downloadArgsForCall)
 }
 
-func (fake *FakeStorageClient) DownloadCalls(stub func(string, *os.File) error) {
+func (fake *FakeStorageClient) DownloadCalls(stub func(string, *os.File, client.DownloadOptions) error) {
 	fake.downloadMutex.Lock()
 	defer fake.downloadMutex.Unlock()
 	fake.DownloadStub = stub
 }
 
-func (fake *FakeStorageClient) DownloadArgsForCall(i int) (string, *os.File) {
+func (fake *FakeStorageClient) DownloadArgsForCall(i int) (string, *os.File, client.DownloadOptions) {
 	fake.downloadMutex.RLock()
 	defer fake.downloadMutex.RUnlock()
 	argsForCall := fake.downloadArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeStorageClient) DownloadReturns(result1 error) {
@@ -383,6 +1031,70 @@ func (fake *FakeStorageClient) DownloadReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeStorageClient) DownloadStream(arg1 string) (io.ReadCloser, error) {
+	fake.downloadStreamMutex.Lock()
+	ret, specificReturn := fake.downloadStreamReturnsOnCall[len(fake.downloadStreamArgsForCall)]
+	fake.downloadStreamArgsForCall = append(fake.downloadStreamArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DownloadStreamStub
+	fakeReturns := fake.downloadStreamReturns
+	fake.recordInvocation("DownloadStream", []interface{}{arg1})
+	fake.downloadStreamMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeStorageClient) DownloadStreamCallCount() int {
+	fake.downloadStreamMutex.RLock()
+	defer fake.downloadStreamMutex.RUnlock()
+	return len(fake.downloadStreamArgsForCall)
+}
+
+func (fake *FakeStorageClient) DownloadStreamCalls(stub func(string) (io.ReadCloser, error)) {
+	fake.downloadStreamMutex.Lock()
+	defer fake.downloadStreamMutex.Unlock()
+	fake.DownloadStreamStub = stub
+}
+
+func (fake *FakeStorageClient) DownloadStreamArgsForCall(i int) string {
+	fake.downloadStreamMutex.RLock()
+	defer fake.downloadStreamMutex.RUnlock()
+	argsForCall := fake.downloadStreamArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeStorageClient) DownloadStreamReturns(result1 io.ReadCloser, result2 error) {
+	fake.downloadStreamMutex.Lock()
+	defer fake.downloadStreamMutex.Unlock()
+	fake.DownloadStreamStub = nil
+	fake.downloadStreamReturns = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStorageClient) DownloadStreamReturnsOnCall(i int, result1 io.ReadCloser, result2 error) {
+	fake.downloadStreamMutex.Lock()
+	defer fake.downloadStreamMutex.Unlock()
+	fake.DownloadStreamStub = nil
+	if fake.downloadStreamReturnsOnCall == nil {
+		fake.downloadStreamReturnsOnCall = make(map[int]struct {
+			result1 io.ReadCloser
+			result2 error
+		})
+	}
+	fake.downloadStreamReturnsOnCall[i] = struct {
+		result1 io.ReadCloser
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeStorageClient) EnsureContainerExists() error {
 	fake.ensureContainerExistsMutex.Lock()
 	ret, specificReturn := fake.ensureContainerExistsReturnsOnCall[len(fake.ensureContainerExistsArgsForCall)]
@@ -500,18 +1212,84 @@ func (fake *FakeStorageClient) ExistsReturnsOnCall(i int, result1 bool, result2
 	}{result1, result2}
 }
 
-func (fake *FakeStorageClient) List(arg1 string) ([]string, error) {
+func (fake *FakeStorageClient) GetProperties(arg1 string) (client.BlobProperties, error) {
+	fake.getPropertiesMutex.Lock()
+	ret, specificReturn := fake.getPropertiesReturnsOnCall[len(fake.getPropertiesArgsForCall)]
+	fake.getPropertiesArgsForCall = append(fake.getPropertiesArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.GetPropertiesStub
+	fakeReturns := fake.getPropertiesReturns
+	fake.recordInvocation("GetProperties", []interface{}{arg1})
+	fake.getPropertiesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeStorageClient) GetPropertiesCallCount() int {
+	fake.getPropertiesMutex.RLock()
+	defer fake.getPropertiesMutex.RUnlock()
+	return len(fake.getPropertiesArgsForCall)
+}
+
+func (fake *FakeStorageClient) GetPropertiesCalls(stub func(string) (client.BlobProperties, error)) {
+	fake.getPropertiesMutex.Lock()
+	defer fake.getPropertiesMutex.Unlock()
+	fake.GetPropertiesStub = stub
+}
+
+func (fake *FakeStorageClient) GetPropertiesArgsForCall(i int) string {
+	fake.getPropertiesMutex.RLock()
+	defer fake.getPropertiesMutex.RUnlock()
+	argsForCall := fake.getPropertiesArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeStorageClient) GetPropertiesReturns(result1 client.BlobProperties, result2 error) {
+	fake.getPropertiesMutex.Lock()
+	defer fake.getPropertiesMutex.Unlock()
+	fake.GetPropertiesStub = nil
+	fake.getPropertiesReturns = struct {
+		result1 client.BlobProperties
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStorageClient) GetPropertiesReturnsOnCall(i int, result1 client.BlobProperties, result2 error) {
+	fake.getPropertiesMutex.Lock()
+	defer fake.getPropertiesMutex.Unlock()
+	fake.GetPropertiesStub = nil
+	if fake.getPropertiesReturnsOnCall == nil {
+		fake.getPropertiesReturnsOnCall = make(map[int]struct {
+			result1 client.BlobProperties
+			result2 error
+		})
+	}
+	fake.getPropertiesReturnsOnCall[i] = struct {
+		result1 client.BlobProperties
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeStorageClient) List(arg1 string, arg2 client.ListOptions, arg3 func(items []*client.BlobItem) error) (string, error) {
 	fake.listMutex.Lock()
 	ret, specificReturn := fake.listReturnsOnCall[len(fake.listArgsForCall)]
 	fake.listArgsForCall = append(fake.listArgsForCall, struct {
 		arg1 string
-	}{arg1})
+		arg2 client.ListOptions
+		arg3 func(items []*client.BlobItem) error
+	}{arg1, arg2, arg3})
 	stub := fake.ListStub
 	fakeReturns := fake.listReturns
-	fake.recordInvocation("List", []interface{}{arg1})
+	fake.recordInvocation("List", []interface{}{arg1, arg2, arg3})
 	fake.listMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -525,45 +1303,108 @@ func (fake *FakeStorageClient) ListCallCount() int {
 	return len(fake.listArgsForCall)
 }
 
-func (fake *FakeStorageClient) ListCalls(stub func(string) ([]string, error)) {
+func (fake *FakeStorageClient) ListCalls(stub func(string, client.ListOptions, func(items []*client.BlobItem) error) (string, error)) {
 	fake.listMutex.Lock()
 	defer fake.listMutex.Unlock()
 	fake.ListStub = stub
 }
 
-func (fake *FakeStorageClient) ListArgsForCall(i int) string {
+func (fake *FakeStorageClient) ListArgsForCall(i int) (string, client.ListOptions, func(items []*client.BlobItem) error) {
 	fake.listMutex.RLock()
 	defer fake.listMutex.RUnlock()
 	argsForCall := fake.listArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
-func (fake *FakeStorageClient) ListReturns(result1 []string, result2 error) {
+func (fake *FakeStorageClient) ListReturns(result1 string, result2 error) {
 	fake.listMutex.Lock()
 	defer fake.listMutex.Unlock()
 	fake.ListStub = nil
 	fake.listReturns = struct {
-		result1 []string
+		result1 string
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *FakeStorageClient) ListReturnsOnCall(i int, result1 []string, result2 error) {
+func (fake *FakeStorageClient) ListReturnsOnCall(i int, result1 string, result2 error) {
 	fake.listMutex.Lock()
 	defer fake.listMutex.Unlock()
 	fake.ListStub = nil
 	if fake.listReturnsOnCall == nil {
 		fake.listReturnsOnCall = make(map[int]struct {
-			result1 []string
+			result1 string
 			result2 error
 		})
 	}
 	fake.listReturnsOnCall[i] = struct {
-		result1 []string
+		result1 string
 		result2 error
 	}{result1, result2}
 }
 
+func (fake *FakeStorageClient) PromoteSnapshot(arg1 context.Context, arg2 string, arg3 string) error {
+	fake.promoteSnapshotMutex.Lock()
+	ret, specificReturn := fake.promoteSnapshotReturnsOnCall[len(fake.promoteSnapshotArgsForCall)]
+	fake.promoteSnapshotArgsForCall = append(fake.promoteSnapshotArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.PromoteSnapshotStub
+	fakeReturns := fake.promoteSnapshotReturns
+	fake.recordInvocation("PromoteSnapshot", []interface{}{arg1, arg2, arg3})
+	fake.promoteSnapshotMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeStorageClient) PromoteSnapshotCallCount() int {
+	fake.promoteSnapshotMutex.RLock()
+	defer fake.promoteSnapshotMutex.RUnlock()
+	return len(fake.promoteSnapshotArgsForCall)
+}
+
+func (fake *FakeStorageClient) PromoteSnapshotCalls(stub func(context.Context, string, string) error) {
+	fake.promoteSnapshotMutex.Lock()
+	defer fake.promoteSnapshotMutex.Unlock()
+	fake.PromoteSnapshotStub = stub
+}
+
+func (fake *FakeStorageClient) PromoteSnapshotArgsForCall(i int) (context.Context, string, string) {
+	fake.promoteSnapshotMutex.RLock()
+	defer fake.promoteSnapshotMutex.RUnlock()
+	argsForCall := fake.promoteSnapshotArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeStorageClient) PromoteSnapshotReturns(result1 error) {
+	fake.promoteSnapshotMutex.Lock()
+	defer fake.promoteSnapshotMutex.Unlock()
+	fake.PromoteSnapshotStub = nil
+	fake.promoteSnapshotReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) PromoteSnapshotReturnsOnCall(i int, result1 error) {
+	fake.promoteSnapshotMutex.Lock()
+	defer fake.promoteSnapshotMutex.Unlock()
+	fake.PromoteSnapshotStub = nil
+	if fake.promoteSnapshotReturnsOnCall == nil {
+		fake.promoteSnapshotReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.promoteSnapshotReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeStorageClient) Properties(arg1 string) error {
 	fake.propertiesMutex.Lock()
 	ret, specificReturn := fake.propertiesReturnsOnCall[len(fake.propertiesArgsForCall)]
@@ -625,6 +1466,194 @@ func (fake *FakeStorageClient) PropertiesReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeStorageClient) ReleaseLease(arg1 string, arg2 string) error {
+	fake.releaseLeaseMutex.Lock()
+	ret, specificReturn := fake.releaseLeaseReturnsOnCall[len(fake.releaseLeaseArgsForCall)]
+	fake.releaseLeaseArgsForCall = append(fake.releaseLeaseArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.ReleaseLeaseStub
+	fakeReturns := fake.releaseLeaseReturns
+	fake.recordInvocation("ReleaseLease", []interface{}{arg1, arg2})
+	fake.releaseLeaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeStorageClient) ReleaseLeaseCallCount() int {
+	fake.releaseLeaseMutex.RLock()
+	defer fake.releaseLeaseMutex.RUnlock()
+	return len(fake.releaseLeaseArgsForCall)
+}
+
+func (fake *FakeStorageClient) ReleaseLeaseCalls(stub func(string, string) error) {
+	fake.releaseLeaseMutex.Lock()
+	defer fake.releaseLeaseMutex.Unlock()
+	fake.ReleaseLeaseStub = stub
+}
+
+func (fake *FakeStorageClient) ReleaseLeaseArgsForCall(i int) (string, string) {
+	fake.releaseLeaseMutex.RLock()
+	defer fake.releaseLeaseMutex.RUnlock()
+	argsForCall := fake.releaseLeaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeStorageClient) ReleaseLeaseReturns(result1 error) {
+	fake.releaseLeaseMutex.Lock()
+	defer fake.releaseLeaseMutex.Unlock()
+	fake.ReleaseLeaseStub = nil
+	fake.releaseLeaseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) ReleaseLeaseReturnsOnCall(i int, result1 error) {
+	fake.releaseLeaseMutex.Lock()
+	defer fake.releaseLeaseMutex.Unlock()
+	fake.ReleaseLeaseStub = nil
+	if fake.releaseLeaseReturnsOnCall == nil {
+		fake.releaseLeaseReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.releaseLeaseReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) RenewLease(arg1 context.Context, arg2 string, arg3 string) error {
+	fake.renewLeaseMutex.Lock()
+	ret, specificReturn := fake.renewLeaseReturnsOnCall[len(fake.renewLeaseArgsForCall)]
+	fake.renewLeaseArgsForCall = append(fake.renewLeaseArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.RenewLeaseStub
+	fakeReturns := fake.renewLeaseReturns
+	fake.recordInvocation("RenewLease", []interface{}{arg1, arg2, arg3})
+	fake.renewLeaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeStorageClient) RenewLeaseCallCount() int {
+	fake.renewLeaseMutex.RLock()
+	defer fake.renewLeaseMutex.RUnlock()
+	return len(fake.renewLeaseArgsForCall)
+}
+
+func (fake *FakeStorageClient) RenewLeaseCalls(stub func(context.Context, string, string) error) {
+	fake.renewLeaseMutex.Lock()
+	defer fake.renewLeaseMutex.Unlock()
+	fake.RenewLeaseStub = stub
+}
+
+func (fake *FakeStorageClient) RenewLeaseArgsForCall(i int) (context.Context, string, string) {
+	fake.renewLeaseMutex.RLock()
+	defer fake.renewLeaseMutex.RUnlock()
+	argsForCall := fake.renewLeaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeStorageClient) RenewLeaseReturns(result1 error) {
+	fake.renewLeaseMutex.Lock()
+	defer fake.renewLeaseMutex.Unlock()
+	fake.RenewLeaseStub = nil
+	fake.renewLeaseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) RenewLeaseReturnsOnCall(i int, result1 error) {
+	fake.renewLeaseMutex.Lock()
+	defer fake.renewLeaseMutex.Unlock()
+	fake.RenewLeaseStub = nil
+	if fake.renewLeaseReturnsOnCall == nil {
+		fake.renewLeaseReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.renewLeaseReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) RestoreVersion(arg1 context.Context, arg2 string, arg3 string) error {
+	fake.restoreVersionMutex.Lock()
+	ret, specificReturn := fake.restoreVersionReturnsOnCall[len(fake.restoreVersionArgsForCall)]
+	fake.restoreVersionArgsForCall = append(fake.restoreVersionArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.RestoreVersionStub
+	fakeReturns := fake.restoreVersionReturns
+	fake.recordInvocation("RestoreVersion", []interface{}{arg1, arg2, arg3})
+	fake.restoreVersionMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeStorageClient) RestoreVersionCallCount() int {
+	fake.restoreVersionMutex.RLock()
+	defer fake.restoreVersionMutex.RUnlock()
+	return len(fake.restoreVersionArgsForCall)
+}
+
+func (fake *FakeStorageClient) RestoreVersionCalls(stub func(context.Context, string, string) error) {
+	fake.restoreVersionMutex.Lock()
+	defer fake.restoreVersionMutex.Unlock()
+	fake.RestoreVersionStub = stub
+}
+
+func (fake *FakeStorageClient) RestoreVersionArgsForCall(i int) (context.Context, string, string) {
+	fake.restoreVersionMutex.RLock()
+	defer fake.restoreVersionMutex.RUnlock()
+	argsForCall := fake.restoreVersionArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeStorageClient) RestoreVersionReturns(result1 error) {
+	fake.restoreVersionMutex.Lock()
+	defer fake.restoreVersionMutex.Unlock()
+	fake.RestoreVersionStub = nil
+	fake.restoreVersionReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) RestoreVersionReturnsOnCall(i int, result1 error) {
+	fake.restoreVersionMutex.Lock()
+	defer fake.restoreVersionMutex.Unlock()
+	fake.RestoreVersionStub = nil
+	if fake.restoreVersionReturnsOnCall == nil {
+		fake.restoreVersionReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.restoreVersionReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeStorageClient) SignedUrl(arg1 string, arg2 string, arg3 time.Duration) (string, error) {
 	fake.signedUrlMutex.Lock()
 	ret, specificReturn := fake.signedUrlReturnsOnCall[len(fake.signedUrlArgsForCall)]
@@ -691,19 +1720,81 @@ func (fake *FakeStorageClient) SignedUrlReturnsOnCall(i int, result1 string, res
 	}{result1, result2}
 }
 
-func (fake *FakeStorageClient) Upload(arg1 io.ReadSeekCloser, arg2 string) ([]byte, error) {
+func (fake *FakeStorageClient) Undelete(arg1 string) error {
+	fake.undeleteMutex.Lock()
+	ret, specificReturn := fake.undeleteReturnsOnCall[len(fake.undeleteArgsForCall)]
+	fake.undeleteArgsForCall = append(fake.undeleteArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.UndeleteStub
+	fakeReturns := fake.undeleteReturns
+	fake.recordInvocation("Undelete", []interface{}{arg1})
+	fake.undeleteMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeStorageClient) UndeleteCallCount() int {
+	fake.undeleteMutex.RLock()
+	defer fake.undeleteMutex.RUnlock()
+	return len(fake.undeleteArgsForCall)
+}
+
+func (fake *FakeStorageClient) UndeleteCalls(stub func(string) error) {
+	fake.undeleteMutex.Lock()
+	defer fake.undeleteMutex.Unlock()
+	fake.UndeleteStub = stub
+}
+
+func (fake *FakeStorageClient) UndeleteArgsForCall(i int) string {
+	fake.undeleteMutex.RLock()
+	defer fake.undeleteMutex.RUnlock()
+	argsForCall := fake.undeleteArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeStorageClient) UndeleteReturns(result1 error) {
+	fake.undeleteMutex.Lock()
+	defer fake.undeleteMutex.Unlock()
+	fake.UndeleteStub = nil
+	fake.undeleteReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) UndeleteReturnsOnCall(i int, result1 error) {
+	fake.undeleteMutex.Lock()
+	defer fake.undeleteMutex.Unlock()
+	fake.UndeleteStub = nil
+	if fake.undeleteReturnsOnCall == nil {
+		fake.undeleteReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.undeleteReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeStorageClient) Upload(arg1 io.ReadSeekCloser, arg2 string, arg3 client.UploadOptions) ([]byte, error) {
 	fake.uploadMutex.Lock()
 	ret, specificReturn := fake.uploadReturnsOnCall[len(fake.uploadArgsForCall)]
 	fake.uploadArgsForCall = append(fake.uploadArgsForCall, struct {
 		arg1 io.ReadSeekCloser
 		arg2 string
-	}{arg1, arg2})
+		arg3 client.UploadOptions
+	}{arg1, arg2, arg3})
 	stub := fake.UploadStub
 	fakeReturns := fake.uploadReturns
-	fake.recordInvocation("Upload", []interface{}{arg1, arg2})
+	fake.recordInvocation("Upload", []interface{}{arg1, arg2, arg3})
 	fake.uploadMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -717,17 +1808,17 @@ func (fake *FakeStorageClient) UploadCallCount() int {
 	return len(fake.uploadArgsForCall)
 }
 
-func (fake *FakeStorageClient) UploadCalls(stub func(io.ReadSeekCloser, string) ([]byte, error)) {
+func (fake *FakeStorageClient) UploadCalls(stub func(io.ReadSeekCloser, string, client.UploadOptions) ([]byte, error)) {
 	fake.uploadMutex.Lock()
 	defer fake.uploadMutex.Unlock()
 	fake.UploadStub = stub
 }
 
-func (fake *FakeStorageClient) UploadArgsForCall(i int) (io.ReadSeekCloser, string) {
+func (fake *FakeStorageClient) UploadArgsForCall(i int) (io.ReadSeekCloser, string, client.UploadOptions) {
 	fake.uploadMutex.RLock()
 	defer fake.uploadMutex.RUnlock()
 	argsForCall := fake.uploadArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
 func (fake *FakeStorageClient) UploadReturns(result1 []byte, result2 error) {
@@ -759,26 +1850,6 @@ func (fake *FakeStorageClient) UploadReturnsOnCall(i int, result1 []byte, result
 func (fake *FakeStorageClient) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.copyMutex.RLock()
-	defer fake.copyMutex.RUnlock()
-	fake.deleteMutex.RLock()
-	defer fake.deleteMutex.RUnlock()
-	fake.deleteRecursiveMutex.RLock()
-	defer fake.deleteRecursiveMutex.RUnlock()
-	fake.downloadMutex.RLock()
-	defer fake.downloadMutex.RUnlock()
-	fake.ensureContainerExistsMutex.RLock()
-	defer fake.ensureContainerExistsMutex.RUnlock()
-	fake.existsMutex.RLock()
-	defer fake.existsMutex.RUnlock()
-	fake.listMutex.RLock()
-	defer fake.listMutex.RUnlock()
-	fake.propertiesMutex.RLock()
-	defer fake.propertiesMutex.RUnlock()
-	fake.signedUrlMutex.RLock()
-	defer fake.signedUrlMutex.RUnlock()
-	fake.uploadMutex.RLock()
-	defer fake.uploadMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+type CopyRecursiveOptions struct {
+	// Source is the blobstore to copy from, which may use another container
+	// or storage account. Nil copies within this blobstore.
+	Source *AzBlobstore
+	// Concurrency is the number of blobs copied at the same time.
+	Concurrency int
+	// ContinueOnError keeps copying the remaining blobs after a blob could
+	// not be copied. Otherwise no further blobs are started.
+	ContinueOnError bool
+	// Copy sets the properties of every copy.
+	Copy CopyOptions
+}
+
+var errStopCopying = errors.New("stop copying")
+
+// CopyRecursive copies every blob below srcPrefix of the source that passes
+// filter to the same name below dstPrefix on the service side. Destinations
+// which already have the length and MD5 of their source are skipped, so
+// copying the same prefixes again resumes an interrupted run. Blobs which
+// could not be copied are returned as BlobFailures.
+func (client *AzBlobstore) CopyRecursive(ctx context.Context, srcPrefix string, dstPrefix string, filter ListFilter, options CopyRecursiveOptions) (TransferSummary, error) {
+	source := options.Source
+	if source == nil {
+		source = client
+	}
+	if client.sameContainer(source) {
+		if err := checkPrefixes("copy", srcPrefix, dstPrefix); err != nil {
+			return TransferSummary{}, err
+		}
+	}
+	if err := options.Copy.validate(); err != nil {
+		return TransferSummary{}, err
+	}
+
+	copied := startProgress("Copied", "blobs")
+
+	var mutex sync.Mutex
+	var summary TransferSummary
+	failures := &BlobFailures{Operation: "copy", Errors: map[string]error{}}
+
+	_, err := source.List(srcPrefix, filter, ListOptions{}, func(items []*BlobItem) error {
+		forEachParallelContext(ctx, items, options.Concurrency, func(item *BlobItem) {
+			src := blobName(item)
+			srcProps := itemProperties(item)
+			skipped, err := client.copyFrom(ctx, source, src, rebaseName(src, srcPrefix, dstPrefix), srcProps, options.Copy)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			switch {
+			case err != nil:
+				failures.Errors[src] = err
+				summary.Failed++
+			case skipped:
+				summary.Skipped++
+			default:
+				summary.Transferred++
+				summary.Bytes += srcProps.ContentLength
+				copied.add(1)
+			}
+		})
+
+		if len(failures.Errors) > 0 && !options.ContinueOnError {
+			return errStopCopying
+		}
+		return ctx.Err()
+	})
+	copied.stop()
+
+	if errors.Is(err, errStopCopying) {
+		log.Println("Stopped copying after the first failure")
+		err = nil
+	}
+	return summary, failures.join(err)
+}
+
+// sameContainer reports whether source is the container of this blobstore,
+// possibly configured separately, e.g. through --source-container.
+func (client *AzBlobstore) sameContainer(source *AzBlobstore) bool {
+	return source == client ||
+		strings.EqualFold(source.storageClient.ContainerURL(), client.storageClient.ContainerURL())
+}
+
+// copyFrom copies src of source, which had srcProps when it was listed, to
+// dst unless dst is already up to date. It reports whether it was skipped.
+func (client *AzBlobstore) copyFrom(ctx context.Context, source *AzBlobstore, src string, dst string, srcProps BlobProperties, options CopyOptions) (bool, error) {
+	upToDate, err := client.upToDate(dst, srcProps)
+	if err != nil || upToDate {
+		return upToDate, err
+	}
+
+	return false, client.copySigned(ctx, source, src, dst, options)
+}
+
+// copySigned copies src of source to dst on the service side, reading src
+// through a read-only SAS URL so that source may be another account.
+func (client *AzBlobstore) copySigned(ctx context.Context, source *AzBlobstore, src string, dst string, options CopyOptions) error {
+	srcURL, err := source.storageClient.CopySourceURL(src, copySourceExpiration)
+	if err != nil {
+		return fmt.Errorf("failed to sign copy source %s: %w", src, err)
+	}
+	return client.storageClient.CopyFromURL(ctx, srcURL, dst, options)
+}
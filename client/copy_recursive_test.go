@@ -0,0 +1,121 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	azContainer "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client"
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client/clientfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CopyRecursive", func() {
+	var (
+		storageClient       *clientfakes.FakeStorageClient
+		sourceStorageClient *clientfakes.FakeStorageClient
+		azBlobstore         client.AzBlobstore
+		source              client.AzBlobstore
+	)
+
+	sizedItem := func(name string, size int64, md5 []byte) *client.BlobItem {
+		return &client.BlobItem{
+			Name:       &name,
+			Properties: &azContainer.BlobProperties{ContentLength: &size, ContentMD5: md5},
+		}
+	}
+
+	BeforeEach(func() {
+		sourceStorageClient = &clientfakes.FakeStorageClient{}
+		sourceStorageClient.ListStub = listPages([]*client.BlobItem{
+			sizedItem("stemcells/a", 3, []byte{1}),
+			sizedItem("stemcells/b", 5, []byte{2}),
+			sizedItem("stemcells/c", 7, nil),
+		})
+		sourceStorageClient.CopySourceURLStub = func(name string, _ time.Duration) (string, error) {
+			return "https://staging.blob.core.windows.net/c/" + name + "?sig=x", nil
+		}
+		sourceStorageClient.ContainerURLReturns("https://staging.blob.core.windows.net/c")
+		source, _ = client.New(sourceStorageClient) //nolint:errcheck
+
+		storageClient = &clientfakes.FakeStorageClient{}
+		storageClient.GetPropertiesStub = func(name string) (client.BlobProperties, error) {
+			if name == "copies/a" {
+				return client.BlobProperties{ContentLength: 3, ContentMD5: []byte{1}}, nil
+			}
+			return client.BlobProperties{}, &azcore.ResponseError{ErrorCode: string(bloberror.BlobNotFound), StatusCode: 404}
+		}
+		storageClient.ContainerURLReturns("https://production.blob.core.windows.net/c")
+		azBlobstore, _ = client.New(storageClient) //nolint:errcheck
+	})
+
+	It("copies every blob from the source and skips up to date destinations", func() {
+		summary, err := azBlobstore.CopyRecursive(context.Background(), "stemcells/", "copies/", client.ListFilter{}, client.CopyRecursiveOptions{
+			Source:      &source,
+			Concurrency: 2,
+			Copy:        client.CopyOptions{Tier: "cool"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary).To(Equal(client.TransferSummary{Transferred: 2, Skipped: 1, Bytes: 12}))
+
+		Expect(storageClient.CopyFromURLCallCount()).To(Equal(2))
+		var destinations []string
+		for i := range storageClient.CopyFromURLCallCount() {
+			_, srcURL, dst, options := storageClient.CopyFromURLArgsForCall(i)
+			Expect(srcURL).To(HavePrefix("https://staging.blob.core.windows.net/c/stemcells/"))
+			Expect(options.Tier).To(Equal("Cool"))
+			destinations = append(destinations, dst)
+		}
+		Expect(destinations).To(ConsistOf("copies/b", "copies/c"))
+	})
+
+	It("reports the blobs which could not be copied", func() {
+		storageClient.CopyFromURLReturns(errors.New("boom"))
+
+		summary, err := azBlobstore.CopyRecursive(context.Background(), "stemcells/", "copies/", client.ListFilter{}, client.CopyRecursiveOptions{Source: &source})
+		Expect(summary).To(Equal(client.TransferSummary{Skipped: 1, Failed: 2}))
+		Expect(err).To(MatchError(ContainSubstring("failed to copy 2 blobs")))
+		Expect(summary.String()).To(Equal("0 transferred (0 bytes), 1 skipped, 2 failed"))
+	})
+
+	It("starts no further copies once the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		storageClient.CopyFromURLStub = func(context.Context, string, string, client.CopyOptions) error {
+			cancel()
+			return nil
+		}
+
+		summary, err := azBlobstore.CopyRecursive(ctx, "stemcells/", "copies/", client.ListFilter{}, client.CopyRecursiveOptions{
+			Source:      &source,
+			Concurrency: 1,
+		})
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(storageClient.CopyFromURLCallCount()).To(Equal(1))
+		Expect(summary.Transferred).To(BeEquivalentTo(1))
+	})
+
+	It("rejects overlapping prefixes within the same container", func() {
+		_, err := azBlobstore.CopyRecursive(context.Background(), "stemcells/", "stemcells/copies/", client.ListFilter{}, client.CopyRecursiveOptions{})
+		Expect(err).To(MatchError("cannot copy 'stemcells/' to 'stemcells/copies/', the prefixes overlap"))
+	})
+
+	It("rejects overlapping prefixes of a source configured for the same container", func() {
+		sourceStorageClient.ContainerURLReturns("https://PRODUCTION.blob.core.windows.net/c")
+
+		_, err := azBlobstore.CopyRecursive(context.Background(), "stemcells/", "stemcells/copies/", client.ListFilter{}, client.CopyRecursiveOptions{Source: &source})
+		Expect(err).To(MatchError("cannot copy 'stemcells/' to 'stemcells/copies/', the prefixes overlap"))
+		Expect(storageClient.CopyFromURLCallCount()).To(Equal(0))
+	})
+
+	It("copies to the same prefix of another container", func() {
+		_, err := azBlobstore.CopyRecursive(context.Background(), "stemcells/", "stemcells/", client.ListFilter{}, client.CopyRecursiveOptions{Source: &source})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(storageClient.CopyFromURLCallCount()).To(Equal(3))
+	})
+})
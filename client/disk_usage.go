@@ -0,0 +1,145 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// UsageTotals counts the blobs and bytes of one group of blobs.
+type UsageTotals struct {
+	Count int64 `json:"count"`
+	Bytes int64 `json:"bytes"`
+}
+
+// PrefixUsage holds the totals of all blobs below Prefix, optionally broken
+// down by access tier.
+type PrefixUsage struct {
+	Prefix string `json:"prefix"`
+	UsageTotals
+	Tiers map[string]*UsageTotals `json:"tiers,omitempty"`
+}
+
+// DiskUsage summarises a listing per prefix level.
+type DiskUsage struct {
+	Total    PrefixUsage    `json:"total"`
+	Prefixes []*PrefixUsage `json:"prefixes"`
+}
+
+type DiskUsageOptions struct {
+	// Depth is the number of '/' separated levels below the listed prefix
+	// to report on. Zero only reports the total.
+	Depth int
+	// ByTier breaks every total down by access tier.
+	ByTier bool
+}
+
+// DiskUsage walks all blobs below prefix and sums up their count and size
+// per prefix level.
+func (client *AzBlobstore) DiskUsage(prefix string, options DiskUsageOptions) (*DiskUsage, error) {
+	usage := &DiskUsage{Total: PrefixUsage{Prefix: prefix}}
+	byPrefix := map[string]*PrefixUsage{}
+
+	_, err := client.List(prefix, ListFilter{}, ListOptions{}, func(items []*BlobItem) error {
+		for _, item := range items {
+			usage.Total.add(item, options.ByTier)
+
+			if options.Depth == 0 {
+				continue
+			}
+
+			level := usagePrefix(prefix, blobName(item), options.Depth)
+			entry, ok := byPrefix[level]
+			if !ok {
+				entry = &PrefixUsage{Prefix: level}
+				byPrefix[level] = entry
+				usage.Prefixes = append(usage.Prefixes, entry)
+			}
+			entry.add(item, options.ByTier)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(usage.Prefixes, func(i, j int) bool {
+		return usage.Prefixes[i].Prefix < usage.Prefixes[j].Prefix
+	})
+	return usage, nil
+}
+
+// WriteText prints one line per prefix (and tier) followed by the total.
+func (usage *DiskUsage) WriteText(out io.Writer) error {
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	entries := make([]*PrefixUsage, 0, len(usage.Prefixes)+1)
+	entries = append(entries, usage.Prefixes...)
+	entries = append(entries, &usage.Total)
+
+	for _, entry := range entries {
+		name := entry.Prefix
+		if entry == &usage.Total {
+			name = "total"
+		} else if name == "" {
+			name = "."
+		}
+		if _, err := fmt.Fprintf(table, "%d\t%d\t%s\n", entry.Bytes, entry.Count, name); err != nil {
+			return err
+		}
+
+		tiers := make([]string, 0, len(entry.Tiers))
+		for tier := range entry.Tiers {
+			tiers = append(tiers, tier)
+		}
+		sort.Strings(tiers)
+		for _, tier := range tiers {
+			totals := entry.Tiers[tier]
+			if _, err := fmt.Fprintf(table, "%d\t%d\t%s [%s]\n", totals.Bytes, totals.Count, name, tier); err != nil {
+				return err
+			}
+		}
+	}
+	return table.Flush()
+}
+
+func (entry *PrefixUsage) add(item *BlobItem, byTier bool) {
+	var size int64
+	if item.Properties != nil && item.Properties.ContentLength != nil {
+		size = *item.Properties.ContentLength
+	}
+	entry.Count++
+	entry.Bytes += size
+
+	if !byTier {
+		return
+	}
+	if entry.Tiers == nil {
+		entry.Tiers = map[string]*UsageTotals{}
+	}
+	tier := blobTier(item)
+	if entry.Tiers[tier] == nil {
+		entry.Tiers[tier] = &UsageTotals{}
+	}
+	entry.Tiers[tier].Count++
+	entry.Tiers[tier].Bytes += size
+}
+
+// usagePrefix returns the prefix of name that is at most depth '/' separated
+// levels below prefix. Blobs directly at a shallower level are reported on
+// their parent level.
+func usagePrefix(prefix string, name string, depth int) string {
+	rest := strings.TrimPrefix(name, prefix)
+	segments := strings.Split(rest, "/")
+	// The last segment is the blob itself, not a level.
+	levels := segments[:len(segments)-1]
+	if len(levels) > depth {
+		levels = levels[:depth]
+	}
+	if len(levels) == 0 {
+		return prefix
+	}
+	return prefix + strings.Join(levels, "/") + "/"
+}
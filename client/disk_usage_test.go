@@ -0,0 +1,78 @@
+package client_test
+
+import (
+	"bytes"
+
+	azBlob "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	azContainer "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client"
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client/clientfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DiskUsage", func() {
+	var azBlobstore client.AzBlobstore
+
+	tieredItem := func(name string, size int64, tier azBlob.AccessTier) *client.BlobItem {
+		return &client.BlobItem{
+			Name:       &name,
+			Properties: &azContainer.BlobProperties{ContentLength: &size, AccessTier: &tier},
+		}
+	}
+
+	BeforeEach(func() {
+		storageClient := clientfakes.FakeStorageClient{}
+		storageClient.ListStub = listPages(
+			[]*client.BlobItem{
+				tieredItem("root-blob", 1, azBlob.AccessTierHot),
+				tieredItem("packages/a", 10, azBlob.AccessTierHot),
+				tieredItem("packages/linux/b", 20, azBlob.AccessTierCool),
+			},
+			[]*client.BlobItem{
+				tieredItem("jobs/c", 100, azBlob.AccessTierHot),
+			},
+		)
+		azBlobstore, _ = client.New(&storageClient) //nolint:errcheck
+	})
+
+	It("sums up count and bytes per prefix level", func() {
+		usage, err := azBlobstore.DiskUsage("", client.DiskUsageOptions{Depth: 1})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(usage.Total.UsageTotals).To(Equal(client.UsageTotals{Count: 4, Bytes: 131}))
+		Expect(usage.Prefixes).To(Equal([]*client.PrefixUsage{
+			{Prefix: "", UsageTotals: client.UsageTotals{Count: 1, Bytes: 1}},
+			{Prefix: "jobs/", UsageTotals: client.UsageTotals{Count: 1, Bytes: 100}},
+			{Prefix: "packages/", UsageTotals: client.UsageTotals{Count: 2, Bytes: 30}},
+		}))
+	})
+
+	It("reports deeper levels and a breakdown by tier", func() {
+		usage, err := azBlobstore.DiskUsage("", client.DiskUsageOptions{Depth: 2, ByTier: true})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(usage.Total.Tiers).To(Equal(map[string]*client.UsageTotals{
+			"Hot":  {Count: 3, Bytes: 111},
+			"Cool": {Count: 1, Bytes: 20},
+		}))
+
+		output := &bytes.Buffer{}
+		Expect(usage.WriteText(output)).To(Succeed())
+		Expect(output.String()).To(Equal(
+			"1    1  .\n" +
+				"1    1  . [Hot]\n" +
+				"100  1  jobs/\n" +
+				"100  1  jobs/ [Hot]\n" +
+				"10   1  packages/\n" +
+				"10   1  packages/ [Hot]\n" +
+				"20   1  packages/linux/\n" +
+				"20   1  packages/linux/ [Cool]\n" +
+				"131  4  total\n" +
+				"20   1  total [Cool]\n" +
+				"111  3  total [Hot]\n",
+		))
+	})
+})
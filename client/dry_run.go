@@ -0,0 +1,115 @@
+package client
+
+import (
+	"fmt"
+	"io"
+)
+
+// DryRunSummary counts the blobs and bytes a destructive command would
+// have affected.
+type DryRunSummary struct {
+	Count int64 `json:"count"`
+	Bytes int64 `json:"bytes"`
+}
+
+func (s DryRunSummary) plus(other DryRunSummary) DryRunSummary {
+	return DryRunSummary{Count: s.Count + other.Count, Bytes: s.Bytes + other.Bytes}
+}
+
+// dryRun writes one line per affected blob to out and keeps count of them.
+type dryRun struct {
+	action string
+	// unit names what is counted in the totals.
+	unit    string
+	out     io.Writer
+	summary DryRunSummary
+}
+
+func newDryRun(action string, out io.Writer) *dryRun {
+	return &dryRun{action: action, unit: "blobs", out: out}
+}
+
+func (d *dryRun) add(item *BlobItem) error {
+	return d.addName(blobName(item), "", itemSize(item))
+}
+
+// addTarget is add for actions which write the blob to another name.
+func (d *dryRun) addTarget(item *BlobItem, target string) error {
+	return d.addName(blobName(item), target, itemSize(item))
+}
+
+// addName is add for blobs or local files known by their name and size.
+// An empty target is left out.
+func (d *dryRun) addName(name string, target string, size int64) error {
+	d.summary.Count++
+	d.summary.Bytes += size
+
+	var err error
+	if target == "" {
+		_, err = fmt.Fprintf(d.out, "would %s %s (%d bytes)\n", d.action, name, size)
+	} else {
+		_, err = fmt.Fprintf(d.out, "would %s %s to %s (%d bytes)\n", d.action, name, target, size)
+	}
+	return err
+}
+
+func itemSize(item *BlobItem) int64 {
+	if item.Properties != nil && item.Properties.ContentLength != nil {
+		return *item.Properties.ContentLength
+	}
+	return 0
+}
+
+// finish writes the totals and returns the summary.
+func (d *dryRun) finish() (DryRunSummary, error) {
+	_, err := fmt.Fprintf(d.out, "would %s %d %s (%d bytes)\n", d.action, d.summary.Count, d.unit, d.summary.Bytes)
+	return d.summary, err
+}
+
+// DryRunDelete writes what Delete would remove to out without deleting
+// anything.
+func (client *AzBlobstore) DryRunDelete(dest string, options DeleteOptions, out io.Writer) (DryRunSummary, error) {
+	listOptions := ListOptions{}
+	if options.Snapshots != "" {
+		listOptions.Include.Snapshots = true
+	}
+
+	preview := newDryRun("delete", out)
+	_, err := client.storageClient.List(dest, listOptions, func(items []*BlobItem) error {
+		for _, item := range items {
+			if blobName(item) != dest {
+				continue
+			}
+			isSnapshot := item.Snapshot != nil && *item.Snapshot != ""
+			if isSnapshot && options.Snapshots == "" || !isSnapshot && options.Snapshots == "only" {
+				continue
+			}
+			if err := preview.add(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return DryRunSummary{}, err
+	}
+	return preview.finish()
+}
+
+// DryRunDeleteRecursive writes what DeleteRecursive would remove to out
+// without deleting anything.
+func (client *AzBlobstore) DryRunDeleteRecursive(prefix string, filter ListFilter, out io.Writer) (DryRunSummary, error) {
+	preview := newDryRun("delete", out)
+	_, err := client.List(prefix, filter, ListOptions{}, func(items []*BlobItem) error {
+		for _, item := range items {
+			if err := preview.add(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return DryRunSummary{}, err
+	}
+	return preview.finish()
+}
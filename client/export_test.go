@@ -0,0 +1,10 @@
+package client
+
+// Exported for tests of polling intervals which would take too long to
+// observe through a copy.
+var NextCopyPollInterval = nextCopyPollInterval
+
+const (
+	CopyPollMinInterval = copyPollMinInterval
+	CopyPollMaxInterval = copyPollMaxInterval
+)
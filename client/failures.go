@@ -0,0 +1,53 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+)
+
+// BlobFailures is returned by bulk operations with the error of every blob
+// the operation failed for, keyed by blob name.
+type BlobFailures struct {
+	Operation string
+	Errors    map[string]error
+}
+
+func (f *BlobFailures) Error() string {
+	names := make([]string, 0, len(f.Errors))
+	for name := range f.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "failed to %s %d blobs:", f.Operation, len(f.Errors))
+	for _, name := range names {
+		fmt.Fprintf(&message, "\n  %s: %s", name, errorSummary(f.Errors[name]))
+	}
+	return message.String()
+}
+
+// join returns err combined with the failures, if there are any.
+func (f *BlobFailures) join(err error) error {
+	if len(f.Errors) == 0 {
+		return err
+	}
+	if err != nil {
+		return errors.Join(err, f)
+	}
+	return f
+}
+
+// errorSummary shortens service errors to their status and error code, as
+// the full error includes the whole response.
+func errorSummary(err error) string {
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) {
+		return fmt.Sprintf("%d %s", respErr.StatusCode, respErr.ErrorCode)
+	}
+	return err.Error()
+}
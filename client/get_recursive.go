@@ -0,0 +1,53 @@
+package client
+
+import (
+	"log"
+	"strings"
+)
+
+type GetRecursiveOptions struct {
+	// Concurrency is the number of blobs downloaded at the same time.
+	Concurrency int
+}
+
+// GetRecursive downloads every blob below prefix that passes filter to its
+// relative path below dir, creating directories as needed. Blob names which
+// would end up outside of dir are rejected, and files are only created
+// through dir, never through symlinks pointing out of it. Each download is
+// verified against the Content-MD5 of its blob. Blobs which could not be
+// downloaded are returned as BlobFailures.
+func (client *AzBlobstore) GetRecursive(prefix string, dir string, filter ListFilter, options GetRecursiveOptions) (TransferSummary, error) {
+	prefix = directoryPrefix(prefix)
+
+	root, err := openDownloadRoot(dir)
+	if err != nil {
+		return TransferSummary{}, err
+	}
+	defer root.Close() //nolint:errcheck
+
+	tally := newTransferTally("download", "Downloaded")
+	_, err = client.List(prefix, filter, ListOptions{}, func(items []*BlobItem) error {
+		forEachParallel(items, options.Concurrency, func(item *BlobItem) {
+			source := blobName(item)
+			props := itemProperties(item)
+			name := strings.TrimPrefix(source, prefix)
+			if strings.HasSuffix(name, "/") {
+				// Directory marker of an account with a hierarchical namespace.
+				return
+			}
+
+			local, err := localName(name)
+			if err != nil {
+				tally.add(source, props.ContentLength, false, err)
+				return
+			}
+			if len(props.ContentMD5) == 0 {
+				log.Printf("Blob %s has no Content-MD5, its download cannot be verified", source)
+			}
+			err = client.download(root, source, local, props.ContentMD5)
+			tally.add(source, props.ContentLength, false, err)
+		})
+		return nil
+	})
+	return tally.finish(err)
+}
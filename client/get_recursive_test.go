@@ -0,0 +1,107 @@
+package client_test
+
+import (
+	"crypto/md5"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	azContainer "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client"
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client/clientfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GetRecursive", func() {
+	var (
+		storageClient *clientfakes.FakeStorageClient
+		azBlobstore   client.AzBlobstore
+		dir           string
+		blobs         map[string]string
+		mutex         sync.Mutex
+	)
+
+	readFile := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		blobs = map[string]string{
+			"packages/index":                 "index",
+			"packages/linux/x86/package.tgz": "package",
+		}
+
+		storageClient = &clientfakes.FakeStorageClient{}
+		storageClient.ListStub = func(prefix string, _ client.ListOptions, handlePage func([]*client.BlobItem) error) (string, error) {
+			mutex.Lock()
+			var items []*client.BlobItem
+			for name, content := range blobs {
+				size := int64(len(content))
+				sum := md5.Sum([]byte(content))
+				items = append(items, &client.BlobItem{
+					Name:       &name,
+					Properties: &azContainer.BlobProperties{ContentLength: &size, ContentMD5: sum[:]},
+				})
+			}
+			mutex.Unlock()
+			return "", handlePage(items)
+		}
+		storageClient.DownloadStreamStub = func(name string) (io.ReadCloser, error) {
+			mutex.Lock()
+			content := blobs[name]
+			mutex.Unlock()
+			return io.NopCloser(strings.NewReader(content)), nil
+		}
+		azBlobstore, _ = client.New(storageClient) //nolint:errcheck
+	})
+
+	It("downloads every blob to its relative path below the directory", func() {
+		summary, err := azBlobstore.GetRecursive("packages", dir, client.ListFilter{}, client.GetRecursiveOptions{Concurrency: 2})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary).To(Equal(client.TransferSummary{Transferred: 2, Bytes: 12}))
+
+		Expect(readFile("index")).To(Equal("index"))
+		Expect(readFile("linux/x86/package.tgz")).To(Equal("package"))
+	})
+
+	It("rejects blob names outside of the directory", func() {
+		blobs["packages/../escape"] = "evil"
+
+		summary, err := azBlobstore.GetRecursive("packages/", dir, client.ListFilter{}, client.GetRecursiveOptions{})
+		Expect(summary).To(Equal(client.TransferSummary{Transferred: 2, Bytes: 12, Failed: 1}))
+		Expect(err).To(MatchError(ContainSubstring("refusing to write blob name '../escape' to a local path")))
+		Expect(filepath.Join(filepath.Dir(dir), "escape")).ToNot(BeAnExistingFile())
+	})
+
+	It("does not follow symlinks out of the directory", func() {
+		outside := GinkgoT().TempDir()
+		Expect(os.Symlink(outside, filepath.Join(dir, "linux"))).To(Succeed())
+
+		summary, err := azBlobstore.GetRecursive("packages", dir, client.ListFilter{}, client.GetRecursiveOptions{})
+		Expect(summary).To(Equal(client.TransferSummary{Transferred: 1, Bytes: 5, Failed: 1}))
+		Expect(err).To(MatchError(ContainSubstring("linux/x86/package.tgz")))
+
+		entries, err := os.ReadDir(outside)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(entries).To(BeEmpty())
+	})
+
+	It("reports the blobs whose download does not match their MD5", func() {
+		storageClient.DownloadStreamStub = func(string) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("corrupt")), nil
+		}
+
+		summary, err := azBlobstore.GetRecursive("packages", dir, client.ListFilter{}, client.GetRecursiveOptions{})
+		Expect(summary.Failed).To(Equal(int64(2)))
+		Expect(err).To(MatchError(ContainSubstring("does not match the blob MD5")))
+		Expect(filepath.Join(dir, "index")).ToNot(BeAnExistingFile())
+	})
+})
@@ -0,0 +1,136 @@
+package client
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// ListFilter selects blobs of a listing by name, size and age. The zero
+// value matches every blob.
+type ListFilter struct {
+	// Include keeps only blobs whose name matches at least one of these
+	// path.Match patterns. Patterns without a slash are matched against the
+	// last segment of the name, all others against the full name.
+	Include []string
+	// Exclude drops blobs whose name matches any of these patterns, using the
+	// same rules as Include.
+	Exclude []string
+	// MinSize and MaxSize bound the blob size in bytes. Zero means no bound.
+	MinSize int64
+	MaxSize int64
+	// OlderThan and NewerThan bound the age of a blob, measured from its
+	// LastModified time. Zero means no bound.
+	OlderThan time.Duration
+	NewerThan time.Duration
+}
+
+// Validate checks that all patterns are well-formed and that the bounds
+// do not contradict each other.
+func (f ListFilter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+		}
+	}
+	if f.MinSize < 0 || f.MaxSize < 0 {
+		return fmt.Errorf("sizes must not be negative")
+	}
+	if f.MaxSize > 0 && f.MinSize > f.MaxSize {
+		return fmt.Errorf("min size %d is larger than max size %d", f.MinSize, f.MaxSize)
+	}
+	if f.OlderThan < 0 || f.NewerThan < 0 {
+		return fmt.Errorf("ages must not be negative")
+	}
+	return nil
+}
+
+// Prefix narrows the listing prefix using the literal leading part of the
+// include patterns, so that the service only returns blobs which can match.
+func (f ListFilter) Prefix(prefix string) string {
+	if len(f.Include) == 0 {
+		return prefix
+	}
+	for _, pattern := range f.Include {
+		if !strings.Contains(pattern, "/") {
+			// Matched against the last name segment, which can be anywhere.
+			return prefix
+		}
+	}
+
+	common := literalPrefix(f.Include[0])
+	for _, pattern := range f.Include[1:] {
+		common = commonPrefix(common, literalPrefix(pattern))
+	}
+
+	if strings.HasPrefix(common, prefix) {
+		return common
+	}
+	return prefix
+}
+
+// Match reports whether item passes the filter at the given point in time.
+func (f ListFilter) Match(item *BlobItem, now time.Time) bool {
+	name := blobName(item)
+
+	if len(f.Include) > 0 && !matchesAny(f.Include, name) {
+		return false
+	}
+	if matchesAny(f.Exclude, name) {
+		return false
+	}
+
+	if f.MinSize > 0 || f.MaxSize > 0 {
+		if item.Properties == nil || item.Properties.ContentLength == nil {
+			return false
+		}
+		size := *item.Properties.ContentLength
+		if size < f.MinSize || (f.MaxSize > 0 && size > f.MaxSize) {
+			return false
+		}
+	}
+
+	if f.OlderThan > 0 || f.NewerThan > 0 {
+		if item.Properties == nil || item.Properties.LastModified == nil {
+			return false
+		}
+		age := now.Sub(*item.Properties.LastModified)
+		if f.OlderThan > 0 && age <= f.OlderThan {
+			return false
+		}
+		if f.NewerThan > 0 && age >= f.NewerThan {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		target := name
+		if !strings.Contains(pattern, "/") {
+			target = path.Base(name)
+		}
+		if matched, _ := path.Match(pattern, target); matched { //nolint:errcheck
+			return true
+		}
+	}
+	return false
+}
+
+func literalPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, `*?[\`); i >= 0 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+func commonPrefix(a, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return a[:i]
+}
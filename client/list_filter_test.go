@@ -0,0 +1,72 @@
+package client_test
+
+import (
+	"time"
+
+	azContainer "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ListFilter", func() {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	sizedItem := func(name string, size int64, age time.Duration) *client.BlobItem {
+		lastModified := now.Add(-age)
+		return &client.BlobItem{
+			Name: &name,
+			Properties: &azContainer.BlobProperties{
+				ContentLength: &size,
+				LastModified:  &lastModified,
+			},
+		}
+	}
+
+	It("matches everything when empty", func() {
+		Expect(client.ListFilter{}.Match(blobItem("anything"), now)).To(BeTrue())
+	})
+
+	It("matches include and exclude patterns against the full name", func() {
+		filter := client.ListFilter{Include: []string{"packages/*", "jobs/*"}, Exclude: []string{"*.tmp"}}
+
+		Expect(filter.Match(blobItem("packages/a"), now)).To(BeTrue())
+		Expect(filter.Match(blobItem("jobs/b"), now)).To(BeTrue())
+		Expect(filter.Match(blobItem("packages/a.tmp"), now)).To(BeFalse())
+		Expect(filter.Match(blobItem("stemcells/c"), now)).To(BeFalse())
+	})
+
+	It("bounds the blob size", func() {
+		filter := client.ListFilter{MinSize: 10, MaxSize: 100}
+
+		Expect(filter.Match(sizedItem("small", 9, 0), now)).To(BeFalse())
+		Expect(filter.Match(sizedItem("min", 10, 0), now)).To(BeTrue())
+		Expect(filter.Match(sizedItem("max", 100, 0), now)).To(BeTrue())
+		Expect(filter.Match(sizedItem("large", 101, 0), now)).To(BeFalse())
+		Expect(filter.Match(blobItem("unknown"), now)).To(BeFalse())
+	})
+
+	It("bounds the blob age", func() {
+		day := 24 * time.Hour
+		filter := client.ListFilter{OlderThan: 90 * day, NewerThan: 365 * day}
+
+		Expect(filter.Match(sizedItem("recent", 1, 30*day), now)).To(BeFalse())
+		Expect(filter.Match(sizedItem("old", 1, 100*day), now)).To(BeTrue())
+		Expect(filter.Match(sizedItem("ancient", 1, 400*day), now)).To(BeFalse())
+	})
+
+	It("narrows the prefix to the literal part shared by all include patterns", func() {
+		Expect(client.ListFilter{}.Prefix("pre")).To(Equal("pre"))
+		Expect(client.ListFilter{Include: []string{"pre/packages/*"}}.Prefix("pre")).To(Equal("pre/packages/"))
+		Expect(client.ListFilter{Include: []string{"pre/packages/*", "pre/jobs/*"}}.Prefix("")).To(Equal("pre/"))
+		Expect(client.ListFilter{Include: []string{"*.tgz"}}.Prefix("pre")).To(Equal("pre"))
+	})
+
+	It("rejects malformed patterns and contradicting bounds", func() {
+		Expect(client.ListFilter{Exclude: []string{"[a-"}}.Validate()).To(MatchError(ContainSubstring("invalid pattern")))
+		Expect(client.ListFilter{MinSize: 10, MaxSize: 5}.Validate()).To(MatchError("min size 10 is larger than max size 5"))
+		Expect(client.ListFilter{MinSize: 10, MaxSize: 50}.Validate()).To(Succeed())
+	})
+})
@@ -0,0 +1,116 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type ListFormat int
+
+const (
+	// ListFormatNames prints one blob name per line.
+	ListFormatNames ListFormat = iota
+	// ListFormatLong prints size, last-modified, tier, ETag and name columns.
+	ListFormatLong
+	// ListFormatJSON prints one JSON object per line with all blob properties.
+	ListFormatJSON
+)
+
+// ListWriter renders listed blobs in one of the supported ListFormats.
+type ListWriter struct {
+	format  ListFormat
+	out     io.Writer
+	table   *tabwriter.Writer
+	encoder *json.Encoder
+}
+
+func NewListWriter(out io.Writer, format ListFormat) *ListWriter {
+	return &ListWriter{
+		format:  format,
+		out:     out,
+		table:   tabwriter.NewWriter(out, 0, 0, 2, ' ', 0),
+		encoder: json.NewEncoder(out),
+	}
+}
+
+func (lw *ListWriter) Write(item *BlobItem) error {
+	switch lw.format {
+	case ListFormatLong:
+		_, err := fmt.Fprintf(lw.table, "%s\t%s\t%s\t%s\t%s%s\n",
+			blobSize(item), blobLastModified(item), blobTier(item), blobETag(item), blobName(item), blobQualifiers(item))
+		return err
+	case ListFormatJSON:
+		return lw.encoder.Encode(item)
+	default:
+		_, err := fmt.Fprintf(lw.out, "%s%s\n", blobName(item), blobQualifiers(item))
+		return err
+	}
+}
+
+// Flush writes out any buffered table rows. It must be called once all
+// items have been written.
+func (lw *ListWriter) Flush() error {
+	return lw.table.Flush()
+}
+
+func blobName(item *BlobItem) string {
+	if item.Name == nil {
+		return ""
+	}
+	return *item.Name
+}
+
+// blobQualifiers tells snapshots, versions and soft-deleted blobs apart
+// from the current blob of the same name. It is empty for plain blobs.
+func blobQualifiers(item *BlobItem) string {
+	var qualifiers []string
+	if item.Snapshot != nil && *item.Snapshot != "" {
+		qualifiers = append(qualifiers, "snapshot="+*item.Snapshot)
+	}
+	if item.VersionID != nil && *item.VersionID != "" {
+		qualifiers = append(qualifiers, "version="+*item.VersionID)
+		if item.IsCurrentVersion != nil && *item.IsCurrentVersion {
+			qualifiers = append(qualifiers, "current")
+		}
+	}
+	if item.Deleted != nil && *item.Deleted {
+		qualifiers = append(qualifiers, "deleted")
+	}
+
+	if len(qualifiers) == 0 {
+		return ""
+	}
+	return "\t" + strings.Join(qualifiers, " ")
+}
+
+func blobSize(item *BlobItem) string {
+	if item.Properties == nil || item.Properties.ContentLength == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d", *item.Properties.ContentLength)
+}
+
+func blobLastModified(item *BlobItem) string {
+	if item.Properties == nil || item.Properties.LastModified == nil {
+		return "-"
+	}
+	return item.Properties.LastModified.UTC().Format(time.RFC3339)
+}
+
+func blobTier(item *BlobItem) string {
+	if item.Properties == nil || item.Properties.AccessTier == nil {
+		return "-"
+	}
+	return string(*item.Properties.AccessTier)
+}
+
+func blobETag(item *BlobItem) string {
+	if item.Properties == nil || item.Properties.ETag == nil {
+		return "-"
+	}
+	return strings.Trim(string(*item.Properties.ETag), `"`)
+}
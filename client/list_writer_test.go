@@ -0,0 +1,120 @@
+package client_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	azBlob "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	azContainer "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ListWriter", func() {
+	var (
+		output *bytes.Buffer
+		items  []*client.BlobItem
+	)
+
+	BeforeEach(func() {
+		output = &bytes.Buffer{}
+
+		name := "some/blob"
+		size := int64(1024)
+		lastModified := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+		tier := azBlob.AccessTierHot
+		etag := azcore.ETag(`"0x8DC"`)
+
+		items = []*client.BlobItem{
+			{
+				Name: &name,
+				Properties: &azContainer.BlobProperties{
+					ContentLength: &size,
+					LastModified:  &lastModified,
+					AccessTier:    &tier,
+					ETag:          &etag,
+				},
+			},
+			blobItem("other"),
+		}
+	})
+
+	writeAll := func(format client.ListFormat) {
+		listWriter := client.NewListWriter(output, format)
+		for _, item := range items {
+			Expect(listWriter.Write(item)).To(Succeed())
+		}
+		Expect(listWriter.Flush()).To(Succeed())
+	}
+
+	It("prints only the names by default", func() {
+		writeAll(client.ListFormatNames)
+
+		Expect(output.String()).To(Equal("some/blob\nother\n"))
+	})
+
+	It("prints size, last-modified, tier and ETag columns in long format", func() {
+		writeAll(client.ListFormatLong)
+
+		Expect(output.String()).To(Equal(
+			"1024  2024-03-01T12:30:00Z  Hot  0x8DC  some/blob\n" +
+				"-     -                     -    -      other\n",
+		))
+	})
+
+	It("qualifies snapshots, versions and deleted blobs", func() {
+		snapshot, version, yes := "2024-03-01T12:30:00.0000000Z", "2024-03-02T00:00:00.0000000Z", true
+		items = []*client.BlobItem{
+			{Name: items[0].Name, Snapshot: &snapshot},
+			{Name: items[0].Name, VersionID: &version, IsCurrentVersion: &yes},
+			{Name: items[0].Name, Deleted: &yes},
+		}
+
+		writeAll(client.ListFormatNames)
+
+		Expect(output.String()).To(Equal(
+			"some/blob\tsnapshot=2024-03-01T12:30:00.0000000Z\n" +
+				"some/blob\tversion=2024-03-02T00:00:00.0000000Z current\n" +
+				"some/blob\tdeleted\n",
+		))
+	})
+
+	It("prints one JSON object per line in JSON format", func() {
+		writeAll(client.ListFormatJSON)
+
+		lines := bytes.Split(bytes.TrimSpace(output.Bytes()), []byte("\n"))
+		Expect(lines).To(HaveLen(2))
+
+		var item client.BlobItem
+		Expect(json.Unmarshal(lines[0], &item)).To(Succeed())
+		Expect(*item.Name).To(Equal("some/blob"))
+		Expect(*item.Properties.ContentLength).To(Equal(int64(1024)))
+		Expect(*item.Properties.AccessTier).To(Equal(azBlob.AccessTierHot))
+	})
+})
+
+var _ = Describe("ParseListInclude", func() {
+	It("parses a comma separated list of datasets", func() {
+		include, err := client.ParseListInclude("snapshots,versions, deleted,metadata,tags,uncommittedblobs")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(include).To(Equal(azContainer.ListBlobsInclude{
+			Snapshots: true, Versions: true, Deleted: true, Metadata: true, Tags: true, UncommittedBlobs: true,
+		}))
+	})
+
+	It("includes nothing for an empty value", func() {
+		include, err := client.ParseListInclude("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(include).To(Equal(azContainer.ListBlobsInclude{}))
+	})
+
+	It("rejects unknown datasets", func() {
+		_, err := client.ParseListInclude("snapshots,everything")
+		Expect(err).To(MatchError(ContainSubstring("unknown list dataset 'everything'")))
+	})
+})
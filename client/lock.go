@@ -0,0 +1,196 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+)
+
+type LockOptions struct {
+	// Duration is the lease duration in seconds (15 to 60). The lease is
+	// renewed well before it expires, so it only matters when the holder
+	// dies without releasing the lock. Defaults to 60.
+	Duration int32
+	// Timeout is how long Acquire waits for a lock held by someone else.
+	// Zero waits forever.
+	Timeout time.Duration
+	// RetryInterval is the time between attempts to acquire a held lock.
+	// Defaults to 5 seconds.
+	RetryInterval time.Duration
+	// RenewInterval is the time between lease renewals. Defaults to a third
+	// of Duration, so that a single failed renewal does not lose the lock.
+	RenewInterval time.Duration
+}
+
+// Lock is a mutual exclusion lock shared between processes, implemented as
+// a lease on a lock blob. The lease is renewed in the background for as long
+// as the lock is held.
+type Lock struct {
+	storageClient StorageClient
+	name          string
+	options       LockOptions
+
+	mu      sync.Mutex
+	leaseID string
+	done    chan struct{}
+	stopped chan struct{}
+	lost    chan error
+}
+
+// NewLock returns a Lock on the blob name. The blob is created empty on the
+// first Acquire if it does not exist.
+func (client *AzBlobstore) NewLock(name string, options LockOptions) (*Lock, error) {
+	if options.Duration == 0 {
+		options.Duration = 60
+	}
+	if options.Duration < 15 || options.Duration > 60 {
+		return nil, fmt.Errorf("invalid lock duration %d, expected 15 to 60 seconds", options.Duration)
+	}
+	if options.RetryInterval == 0 {
+		options.RetryInterval = 5 * time.Second
+	}
+	if options.RenewInterval == 0 {
+		options.RenewInterval = time.Duration(options.Duration) * time.Second / 3
+	}
+
+	return &Lock{
+		storageClient: client.storageClient,
+		name:          name,
+		options:       options,
+	}, nil
+}
+
+// Acquire blocks until the lock is held or the timeout passes, then keeps
+// the lock alive until Release is called.
+func (l *Lock) Acquire() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.leaseID != "" {
+		return fmt.Errorf("lock %s is already held", l.name)
+	}
+
+	var deadline time.Time
+	if l.options.Timeout > 0 {
+		deadline = time.Now().Add(l.options.Timeout)
+	}
+
+	var acquired time.Time
+	for {
+		acquired = time.Now()
+		leaseID, err := l.storageClient.AcquireLease(l.name, l.options.Duration, "")
+		if err == nil {
+			l.leaseID = leaseID
+			break
+		}
+
+		switch {
+		case bloberror.HasCode(err, bloberror.BlobNotFound):
+			err = l.create()
+			if err != nil && !bloberror.HasCode(err, bloberror.LeaseIDMissing) {
+				return err
+			}
+			// Either created, or someone else created and locked it in the meantime.
+			continue
+		case bloberror.HasCode(err, bloberror.LeaseAlreadyPresent):
+			if !deadline.IsZero() && time.Now().Add(l.options.RetryInterval).After(deadline) {
+				return fmt.Errorf("timed out after %s waiting for lock %s", l.options.Timeout, l.name)
+			}
+			log.Printf("Lock %s is held by someone else, retrying in %s", l.name, l.options.RetryInterval)
+			time.Sleep(l.options.RetryInterval)
+		default:
+			return err
+		}
+	}
+
+	l.done = make(chan struct{})
+	l.stopped = make(chan struct{})
+	l.lost = make(chan error, 1)
+	go l.renew(l.leaseID, acquired, l.done, l.stopped, l.lost)
+	return nil
+}
+
+// Lost returns a channel which receives an error and is closed when the
+// lease could not be renewed. The lock is no longer held at that point.
+func (l *Lock) Lost() <-chan error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.lost
+}
+
+// Release stops renewing the lease and releases it, so that the next
+// holder does not have to wait for it to expire.
+func (l *Lock) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.leaseID == "" {
+		return fmt.Errorf("lock %s is not held", l.name)
+	}
+
+	close(l.done)
+	<-l.stopped
+
+	leaseID := l.leaseID
+	l.leaseID = ""
+	return l.storageClient.ReleaseLease(l.name, leaseID)
+}
+
+func (l *Lock) create() error {
+	log.Printf("Creating lock blob %s", l.name)
+	_, err := l.storageClient.Upload(nopSeekCloser{bytes.NewReader(nil)}, l.name, UploadOptions{})
+	return err
+}
+
+// renew keeps the lease acquired at the given time alive until done is
+// closed. Each renewal has to complete before the lease expires. The lock
+// is lost once the service reports that the lease is gone, or once the
+// next renewal would come too late to keep the lease alive.
+func (l *Lock) renew(leaseID string, renewed time.Time, done <-chan struct{}, stopped chan<- struct{}, lost chan<- error) {
+	defer close(stopped)
+
+	duration := time.Duration(l.options.Duration) * time.Second
+	ticker := time.NewTicker(l.options.RenewInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			// The service counts the lease from when it received the
+			// request, which is no earlier than this.
+			started := time.Now()
+			ctx, cancel := context.WithDeadline(context.Background(), renewed.Add(duration))
+			err := l.storageClient.RenewLease(ctx, l.name, leaseID)
+			cancel()
+			if err == nil {
+				renewed = started
+				continue
+			}
+			log.Printf("Failed to renew lease on lock %s: %s", l.name, err)
+			if bloberror.HasCode(err, bloberror.LeaseIDMismatchWithLeaseOperation, bloberror.LeaseNotPresentWithLeaseOperation, bloberror.LeaseLost) ||
+				time.Since(renewed)+l.options.RenewInterval >= duration {
+				lost <- fmt.Errorf("lost lock %s: %w", l.name, err)
+				close(lost)
+				<-done
+				return
+			}
+		}
+	}
+}
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error {
+	return nil
+}
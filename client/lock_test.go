@@ -0,0 +1,148 @@
+package client_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client"
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client/clientfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Lock", func() {
+	var (
+		storageClient *clientfakes.FakeStorageClient
+		azBlobstore   client.AzBlobstore
+	)
+
+	responseError := func(code bloberror.Code, status int) error {
+		return &azcore.ResponseError{ErrorCode: string(code), StatusCode: status}
+	}
+
+	BeforeEach(func() {
+		storageClient = &clientfakes.FakeStorageClient{}
+		storageClient.AcquireLeaseReturns("some-lease", nil)
+
+		var err error
+		azBlobstore, err = client.New(storageClient)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("acquires and releases a lease on the lock blob", func() {
+		lock, err := azBlobstore.NewLock("locks/index", client.LockOptions{Duration: 30})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(lock.Acquire()).To(Succeed())
+		name, duration, _ := storageClient.AcquireLeaseArgsForCall(0)
+		Expect(name).To(Equal("locks/index"))
+		Expect(duration).To(Equal(int32(30)))
+
+		Expect(lock.Release()).To(Succeed())
+		name, leaseID := storageClient.ReleaseLeaseArgsForCall(0)
+		Expect(name).To(Equal("locks/index"))
+		Expect(leaseID).To(Equal("some-lease"))
+	})
+
+	It("creates the lock blob if it does not exist", func() {
+		storageClient.AcquireLeaseReturnsOnCall(0, "", responseError(bloberror.BlobNotFound, 404))
+
+		lock, err := azBlobstore.NewLock("locks/index", client.LockOptions{})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(lock.Acquire()).To(Succeed())
+		Expect(storageClient.UploadCallCount()).To(Equal(1))
+		_, name, _ := storageClient.UploadArgsForCall(0)
+		Expect(name).To(Equal("locks/index"))
+		Expect(storageClient.AcquireLeaseCallCount()).To(Equal(2))
+
+		Expect(lock.Release()).To(Succeed())
+	})
+
+	It("waits while the lock is held by someone else", func() {
+		storageClient.AcquireLeaseReturnsOnCall(0, "", responseError(bloberror.LeaseAlreadyPresent, 409))
+
+		lock, err := azBlobstore.NewLock("locks/index", client.LockOptions{RetryInterval: time.Millisecond})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(lock.Acquire()).To(Succeed())
+		Expect(storageClient.AcquireLeaseCallCount()).To(Equal(2))
+
+		Expect(lock.Release()).To(Succeed())
+	})
+
+	It("gives up once the timeout passed", func() {
+		storageClient.AcquireLeaseReturns("", responseError(bloberror.LeaseAlreadyPresent, 409))
+
+		lock, err := azBlobstore.NewLock("locks/index", client.LockOptions{Timeout: 20 * time.Millisecond, RetryInterval: 5 * time.Millisecond})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(lock.Acquire()).To(MatchError("timed out after 20ms waiting for lock locks/index"))
+	})
+
+	It("renews the lease while the lock is held", func() {
+		lock, err := azBlobstore.NewLock("locks/index", client.LockOptions{RenewInterval: time.Millisecond})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(lock.Acquire()).To(Succeed())
+		Eventually(storageClient.RenewLeaseCallCount).Should(BeNumerically(">=", 2))
+		Expect(lock.Release()).To(Succeed())
+
+		renewals := storageClient.RenewLeaseCallCount()
+		Consistently(storageClient.RenewLeaseCallCount, 20*time.Millisecond).Should(Equal(renewals))
+
+		_, _, leaseID := storageClient.RenewLeaseArgsForCall(0)
+		Expect(leaseID).To(Equal("some-lease"))
+	})
+
+	It("reports the lock as lost when the lease is gone", func() {
+		storageClient.RenewLeaseReturns(responseError(bloberror.LeaseIDMismatchWithLeaseOperation, 409))
+
+		lock, err := azBlobstore.NewLock("locks/index", client.LockOptions{RenewInterval: time.Millisecond})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(lock.Acquire()).To(Succeed())
+		Eventually(lock.Lost()).Should(Receive(MatchError(ContainSubstring("lost lock locks/index"))))
+		Expect(lock.Release()).To(Succeed())
+	})
+
+	It("reports the lock as lost before the lease expires when renewals keep failing", func() {
+		var (
+			mutex     sync.Mutex
+			deadlines []time.Time
+		)
+		storageClient.RenewLeaseStub = func(ctx context.Context, name string, leaseID string) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			deadline, _ := ctx.Deadline()
+			deadlines = append(deadlines, deadline)
+			return responseError(bloberror.ServerBusy, 503)
+		}
+
+		lock, err := azBlobstore.NewLock("locks/index", client.LockOptions{Duration: 15, RenewInterval: 8 * time.Second})
+		Expect(err).ToNot(HaveOccurred())
+
+		acquired := time.Now()
+		Expect(lock.Acquire()).To(Succeed())
+		Eventually(lock.Lost(), 15*time.Second).Should(Receive(MatchError(ContainSubstring("lost lock locks/index"))))
+		Expect(time.Since(acquired)).To(BeNumerically("<", 15*time.Second))
+		Expect(lock.Release()).To(Succeed())
+
+		mutex.Lock()
+		defer mutex.Unlock()
+		Expect(deadlines).To(HaveLen(1))
+		for _, deadline := range deadlines {
+			Expect(deadline).To(BeTemporally("~", acquired.Add(15*time.Second), time.Second))
+		}
+	})
+
+	It("rejects lease durations the service does not support", func() {
+		_, err := azBlobstore.NewLock("locks/index", client.LockOptions{Duration: 90})
+		Expect(err).To(MatchError("invalid lock duration 90, expected 15 to 60 seconds"))
+	})
+})
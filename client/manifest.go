@@ -0,0 +1,122 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Manifest records the blobs below Prefix at the time it was created.
+type Manifest struct {
+	Prefix    string          `json:"prefix"`
+	CreatedAt time.Time       `json:"created_at"`
+	Blobs     []ManifestEntry `json:"blobs"`
+}
+
+type ManifestEntry struct {
+	Name       string `json:"name"`
+	Size       int64  `json:"size"`
+	ETag       string `json:"etag"`
+	ContentMD5 []byte `json:"content_md5,omitempty"`
+}
+
+// ManifestDiff lists the blob names which no longer match a Manifest.
+type ManifestDiff struct {
+	// Missing blobs are in the manifest but not in the container.
+	Missing []string `json:"missing"`
+	// Changed blobs differ in size or MD5, or in ETag if the manifest has
+	// no MD5 for them.
+	Changed []string `json:"changed"`
+	// Extra blobs are in the container but not in the manifest.
+	Extra []string `json:"extra"`
+}
+
+// Matches reports whether the container still matched the manifest.
+func (d ManifestDiff) Matches() bool {
+	return len(d.Missing) == 0 && len(d.Changed) == 0 && len(d.Extra) == 0
+}
+
+func (d ManifestDiff) String() string {
+	return fmt.Sprintf("%d missing, %d changed, %d extra", len(d.Missing), len(d.Changed), len(d.Extra))
+}
+
+// CreateManifest records the name, size, ETag and Content-MD5 of every blob
+// below prefix, sorted by name.
+func (client *AzBlobstore) CreateManifest(prefix string) (Manifest, error) {
+	manifest := Manifest{Prefix: prefix, CreatedAt: time.Now().UTC(), Blobs: []ManifestEntry{}}
+	_, err := client.List(prefix, ListFilter{}, ListOptions{}, func(items []*BlobItem) error {
+		for _, item := range items {
+			props := itemProperties(item)
+			manifest.Blobs = append(manifest.Blobs, ManifestEntry{
+				Name:       blobName(item),
+				Size:       props.ContentLength,
+				ETag:       props.ETag,
+				ContentMD5: props.ContentMD5,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return Manifest{}, err
+	}
+
+	sort.Slice(manifest.Blobs, func(i, j int) bool {
+		return manifest.Blobs[i].Name < manifest.Blobs[j].Name
+	})
+	return manifest, nil
+}
+
+// VerifyManifest lists the blobs below the prefix of manifest and returns
+// which of them are missing, changed or extra compared to the manifest.
+func (client *AzBlobstore) VerifyManifest(manifest Manifest) (ManifestDiff, error) {
+	diff := ManifestDiff{Missing: []string{}, Changed: []string{}, Extra: []string{}}
+
+	expected := make(map[string]ManifestEntry, len(manifest.Blobs))
+	for _, entry := range manifest.Blobs {
+		expected[entry.Name] = entry
+	}
+
+	seen := map[string]bool{}
+	_, err := client.List(manifest.Prefix, ListFilter{}, ListOptions{}, func(items []*BlobItem) error {
+		for _, item := range items {
+			name := blobName(item)
+			seen[name] = true
+
+			entry, ok := expected[name]
+			if !ok {
+				diff.Extra = append(diff.Extra, name)
+				continue
+			}
+			if entry.changed(itemProperties(item)) {
+				diff.Changed = append(diff.Changed, name)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return ManifestDiff{}, err
+	}
+
+	for _, entry := range manifest.Blobs {
+		if !seen[entry.Name] {
+			diff.Missing = append(diff.Missing, entry.Name)
+		}
+	}
+
+	sort.Strings(diff.Missing)
+	sort.Strings(diff.Changed)
+	sort.Strings(diff.Extra)
+	return diff, nil
+}
+
+// changed reports whether a blob with props no longer matches the entry.
+func (e ManifestEntry) changed(props BlobProperties) bool {
+	if e.Size != props.ContentLength {
+		return true
+	}
+	if len(e.ContentMD5) == 0 {
+		return e.ETag != props.ETag
+	}
+	return !bytes.Equal(e.ContentMD5, props.ContentMD5)
+}
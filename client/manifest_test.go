@@ -0,0 +1,90 @@
+package client_test
+
+import (
+	"encoding/json"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	azContainer "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client"
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client/clientfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Manifest", func() {
+	var (
+		storageClient *clientfakes.FakeStorageClient
+		azBlobstore   client.AzBlobstore
+	)
+
+	item := func(name string, size int64, etag string, md5 []byte) *client.BlobItem {
+		tag := azcore.ETag(`"` + etag + `"`)
+		return &client.BlobItem{
+			Name:       &name,
+			Properties: &azContainer.BlobProperties{ContentLength: &size, ETag: &tag, ContentMD5: md5},
+		}
+	}
+
+	BeforeEach(func() {
+		storageClient = &clientfakes.FakeStorageClient{}
+		storageClient.ListStub = listPages(
+			[]*client.BlobItem{item("releases/b", 5, "0x2", []byte{2})},
+			[]*client.BlobItem{item("releases/a", 3, "0x1", []byte{1})},
+		)
+		azBlobstore, _ = client.New(storageClient) //nolint:errcheck
+	})
+
+	It("records every blob sorted by name", func() {
+		manifest, err := azBlobstore.CreateManifest("releases/")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.Prefix).To(Equal("releases/"))
+		Expect(manifest.CreatedAt).ToNot(BeZero())
+		Expect(manifest.Blobs).To(Equal([]client.ManifestEntry{
+			{Name: "releases/a", Size: 3, ETag: "0x1", ContentMD5: []byte{1}},
+			{Name: "releases/b", Size: 5, ETag: "0x2", ContentMD5: []byte{2}},
+		}))
+
+		output, err := json.Marshal(manifest.Blobs[0])
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(output)).To(Equal(`{"name":"releases/a","size":3,"etag":"0x1","content_md5":"AQ=="}`))
+	})
+
+	It("matches an unchanged container", func() {
+		manifest, err := azBlobstore.CreateManifest("releases/")
+		Expect(err).ToNot(HaveOccurred())
+
+		diff, err := azBlobstore.VerifyManifest(manifest)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diff.Matches()).To(BeTrue())
+	})
+
+	It("reports missing, changed and extra blobs", func() {
+		storageClient.ListStub = listPages([]*client.BlobItem{
+			item("releases/b", 5, "0x3", []byte{9}),
+			item("releases/c", 1, "0x4", nil),
+			item("releases/d", 1, "0x6", nil),
+		})
+
+		diff, err := azBlobstore.VerifyManifest(client.Manifest{
+			Prefix: "releases/",
+			Blobs: []client.ManifestEntry{
+				{Name: "releases/a", Size: 3, ETag: "0x1", ContentMD5: []byte{1}},
+				{Name: "releases/b", Size: 5, ETag: "0x2", ContentMD5: []byte{2}},
+				{Name: "releases/d", Size: 1, ETag: "0x5"},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(diff).To(Equal(client.ManifestDiff{
+			Missing: []string{"releases/a"},
+			Changed: []string{"releases/b", "releases/d"},
+			Extra:   []string{"releases/c"},
+		}))
+		Expect(diff.Matches()).To(BeFalse())
+		Expect(diff.String()).To(Equal("1 missing, 2 changed, 1 extra"))
+
+		prefix, _, _ := storageClient.ListArgsForCall(0)
+		Expect(prefix).To(Equal("releases/"))
+	})
+})
@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+)
+
+type MirrorOptions struct {
+	// Concurrency is the number of blobs copied or deleted at the same time.
+	Concurrency int
+	// Delete removes blobs from the destination which do not exist at the
+	// source.
+	Delete bool
+	// All confirms that Delete may remove blobs from the whole container
+	// when no prefix is given.
+	All bool
+}
+
+// MirrorReport is the machine readable outcome of Mirror.
+type MirrorReport struct {
+	Prefix  string          `json:"prefix"`
+	Summary TransferSummary `json:"summary"`
+	// Copied, Deleted and Failed hold the blob names relative to Prefix.
+	Copied  []string          `json:"copied"`
+	Deleted []string          `json:"deleted"`
+	Failed  map[string]string `json:"failed"`
+}
+
+// Mirror makes the blobs below prefix of this blobstore identical to the
+// ones below prefix of source. Both sides are listed first, and blobs which
+// are missing or differ in length or MD5 are copied on the service side and
+// verified against the MD5 of their source. Blobs without a Content-MD5 are
+// always copied. Mirroring again resumes an interrupted run, as blobs which
+// were already copied are skipped. Blobs which could not be mirrored are
+// returned as BlobFailures.
+func (client *AzBlobstore) Mirror(ctx context.Context, source *AzBlobstore, prefix string, options MirrorOptions) (MirrorReport, error) {
+	prefix = directoryPrefix(prefix)
+	report := MirrorReport{Prefix: prefix, Copied: []string{}, Deleted: []string{}, Failed: map[string]string{}}
+	if err := options.checkDelete(prefix); err != nil {
+		return report, err
+	}
+
+	srcBlobs, dstBlobs, err := client.mirrorSides(source, prefix)
+	if err != nil {
+		return report, err
+	}
+
+	var mutex sync.Mutex
+	record := func(list *[]string, name string, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if err != nil {
+			report.Failed[name] = errorSummary(err)
+			return
+		}
+		*list = append(*list, name)
+	}
+
+	tally := newTransferTally("mirror", "Mirrored")
+	forEachParallelContext(ctx, sortedNames(srcBlobs), options.Concurrency, func(name string) {
+		srcProps := srcBlobs[name]
+		if mirrored(srcProps, dstBlobs, name) {
+			tally.add(name, srcProps.ContentLength, true, nil)
+			return
+		}
+
+		copyOptions := CopyOptions{VerifyMD5: len(srcProps.ContentMD5) > 0}
+		err := client.copySigned(ctx, source, prefix+name, prefix+name, copyOptions)
+		tally.add(name, srcProps.ContentLength, false, err)
+		record(&report.Copied, name, err)
+	})
+
+	// Blobs which were not copied because of an interruption are missing at
+	// the destination, so nothing is deleted then either.
+	if options.Delete && ctx.Err() == nil {
+		forEachParallel(mirrorExtras(srcBlobs, dstBlobs), options.Concurrency, func(name string) {
+			// Only delete the blob as it was listed, in case it was written
+			// in the meantime.
+			err := client.storageClient.Delete(prefix+name, DeleteOptions{IfMatch: dstBlobs[name].ETag})
+			tally.delete(name, err)
+			record(&report.Deleted, name, err)
+		})
+	}
+
+	sort.Strings(report.Copied)
+	sort.Strings(report.Deleted)
+	report.Summary, err = tally.finish(ctx.Err())
+	return report, err
+}
+
+// DryRunMirror writes what Mirror would copy and delete to out without
+// changing anything.
+func (client *AzBlobstore) DryRunMirror(source *AzBlobstore, prefix string, options MirrorOptions, out io.Writer) (DryRunSummary, error) {
+	prefix = directoryPrefix(prefix)
+	if err := options.checkDelete(prefix); err != nil {
+		return DryRunSummary{}, err
+	}
+
+	srcBlobs, dstBlobs, err := client.mirrorSides(source, prefix)
+	if err != nil {
+		return DryRunSummary{}, err
+	}
+
+	copies := newDryRun("copy", out)
+	for _, name := range sortedNames(srcBlobs) {
+		if mirrored(srcBlobs[name], dstBlobs, name) {
+			continue
+		}
+		if err := copies.addName(prefix+name, "", srcBlobs[name].ContentLength); err != nil {
+			return DryRunSummary{}, err
+		}
+	}
+	summary, err := copies.finish()
+	if err != nil || !options.Delete {
+		return summary, err
+	}
+
+	deletes := newDryRun("delete", out)
+	for _, name := range mirrorExtras(srcBlobs, dstBlobs) {
+		if err := deletes.addName(prefix+name, "", dstBlobs[name].ContentLength); err != nil {
+			return DryRunSummary{}, err
+		}
+	}
+	deleted, err := deletes.finish()
+	return summary.plus(deleted), err
+}
+
+// checkDelete refuses to delete extra blobs of the whole container unless
+// All is set.
+func (options MirrorOptions) checkDelete(prefix string) error {
+	if options.Delete && prefix == "" && !options.All {
+		return errors.New("refusing to delete extra blobs of the whole container without a prefix, pass --all to confirm")
+	}
+	return nil
+}
+
+// mirrorSides lists the blobs below prefix of source and of this blobstore
+// keyed by their name relative to prefix.
+func (client *AzBlobstore) mirrorSides(source *AzBlobstore, prefix string) (map[string]BlobProperties, map[string]BlobProperties, error) {
+	srcBlobs, err := source.listRelative(prefix, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	dstBlobs, err := client.listRelative(prefix, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return srcBlobs, dstBlobs, nil
+}
+
+// mirrored reports whether the destination blob name already matches a
+// source with srcProps. Sources without a Content-MD5 never match.
+func mirrored(srcProps BlobProperties, dstBlobs map[string]BlobProperties, name string) bool {
+	dstProps, ok := dstBlobs[name]
+	return ok && len(srcProps.ContentMD5) > 0 && sameContent(srcProps, dstProps)
+}
+
+// mirrorExtras returns the names of the destination blobs which do not
+// exist at the source, in order.
+func mirrorExtras(srcBlobs map[string]BlobProperties, dstBlobs map[string]BlobProperties) []string {
+	var extras []string
+	for _, name := range sortedNames(dstBlobs) {
+		if _, ok := srcBlobs[name]; !ok {
+			extras = append(extras, name)
+		}
+	}
+	return extras
+}
+
+// sortedNames returns the keys of blobs in order.
+func sortedNames(blobs map[string]BlobProperties) []string {
+	names := make([]string, 0, len(blobs))
+	for name := range blobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,219 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	azContainer "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client"
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client/clientfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Mirror", func() {
+	var (
+		storageClient       *clientfakes.FakeStorageClient
+		sourceStorageClient *clientfakes.FakeStorageClient
+		azBlobstore         client.AzBlobstore
+		source              client.AzBlobstore
+		srcBlobs            map[string]string
+		dstBlobs            map[string]string
+		mutex               sync.Mutex
+	)
+
+	listStub := func(blobs map[string]string) func(string, client.ListOptions, func([]*client.BlobItem) error) (string, error) {
+		return func(prefix string, _ client.ListOptions, handlePage func([]*client.BlobItem) error) (string, error) {
+			mutex.Lock()
+			var items []*client.BlobItem
+			for name, content := range blobs {
+				if !strings.HasPrefix(name, prefix) {
+					continue
+				}
+				size := int64(len(content))
+				sum := md5.Sum([]byte(content))
+				etag := azcore.ETag(content)
+				items = append(items, &client.BlobItem{
+					Name:       &name,
+					Properties: &azContainer.BlobProperties{ContentLength: &size, ContentMD5: sum[:], ETag: &etag},
+				})
+			}
+			mutex.Unlock()
+			return "", handlePage(items)
+		}
+	}
+
+	BeforeEach(func() {
+		srcBlobs = map[string]string{
+			"stemcells/same":    "same",
+			"stemcells/changed": "new",
+			"stemcells/missing": "missing",
+			"other/ignored":     "ignored",
+		}
+		dstBlobs = map[string]string{
+			"stemcells/same":    "same",
+			"stemcells/changed": "old",
+			"stemcells/extra":   "extra",
+		}
+
+		sourceStorageClient = &clientfakes.FakeStorageClient{}
+		sourceStorageClient.ListStub = listStub(srcBlobs)
+		sourceStorageClient.CopySourceURLStub = func(name string, _ time.Duration) (string, error) {
+			return "http://127.0.0.1:10000/source/c/" + name + "?sig=x", nil
+		}
+		source, _ = client.New(sourceStorageClient) //nolint:errcheck
+
+		storageClient = &clientfakes.FakeStorageClient{}
+		storageClient.ListStub = listStub(dstBlobs)
+		storageClient.CopyFromURLStub = func(_ context.Context, srcURL string, dst string, _ client.CopyOptions) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			dstBlobs[dst] = srcBlobs[strings.TrimSuffix(strings.TrimPrefix(srcURL, "http://127.0.0.1:10000/source/c/"), "?sig=x")]
+			return nil
+		}
+		storageClient.DeleteStub = func(name string, _ client.DeleteOptions) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			delete(dstBlobs, name)
+			return nil
+		}
+		azBlobstore, _ = client.New(storageClient) //nolint:errcheck
+	})
+
+	It("copies missing and changed blobs and skips identical ones", func() {
+		report, err := azBlobstore.Mirror(context.Background(), &source, "stemcells", client.MirrorOptions{Concurrency: 2})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report).To(Equal(client.MirrorReport{
+			Prefix:  "stemcells/",
+			Summary: client.TransferSummary{Transferred: 2, Skipped: 1, Bytes: 10},
+			Copied:  []string{"changed", "missing"},
+			Deleted: []string{},
+			Failed:  map[string]string{},
+		}))
+
+		Expect(dstBlobs).To(Equal(map[string]string{
+			"stemcells/same":    "same",
+			"stemcells/changed": "new",
+			"stemcells/missing": "missing",
+			"stemcells/extra":   "extra",
+		}))
+		for i := range storageClient.CopyFromURLCallCount() {
+			_, _, _, options := storageClient.CopyFromURLArgsForCall(i)
+			Expect(options.VerifyMD5).To(BeTrue())
+		}
+	})
+
+	It("deletes extra blobs as they were listed", func() {
+		report, err := azBlobstore.Mirror(context.Background(), &source, "stemcells/", client.MirrorOptions{Delete: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Deleted).To(Equal([]string{"extra"}))
+		Expect(report.Summary.Deleted).To(Equal(int64(1)))
+		Expect(dstBlobs).ToNot(HaveKey("stemcells/extra"))
+
+		Expect(storageClient.DeleteCallCount()).To(Equal(1))
+		_, options := storageClient.DeleteArgsForCall(0)
+		Expect(options.IfMatch).To(Equal("extra"))
+	})
+
+	It("refuses to delete extra blobs of the whole container without All", func() {
+		_, err := azBlobstore.Mirror(context.Background(), &source, "", client.MirrorOptions{Delete: true})
+		Expect(err).To(MatchError(ContainSubstring("pass --all to confirm")))
+		Expect(storageClient.CopyFromURLCallCount()).To(Equal(0))
+		Expect(storageClient.DeleteCallCount()).To(Equal(0))
+
+		report, err := azBlobstore.Mirror(context.Background(), &source, "", client.MirrorOptions{Delete: true, All: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Deleted).To(Equal([]string{"stemcells/extra"}))
+	})
+
+	It("prints what would be copied and deleted without changing anything", func() {
+		out := &bytes.Buffer{}
+		summary, err := azBlobstore.DryRunMirror(&source, "stemcells", client.MirrorOptions{Delete: true}, out)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary).To(Equal(client.DryRunSummary{Count: 3, Bytes: 15}))
+		Expect(out.String()).To(Equal(
+			"would copy stemcells/changed (3 bytes)\n" +
+				"would copy stemcells/missing (7 bytes)\n" +
+				"would copy 2 blobs (10 bytes)\n" +
+				"would delete stemcells/extra (5 bytes)\n" +
+				"would delete 1 blobs (5 bytes)\n"))
+		Expect(storageClient.CopyFromURLCallCount()).To(Equal(0))
+		Expect(storageClient.DeleteCallCount()).To(Equal(0))
+	})
+
+	It("skips everything when run again", func() {
+		_, err := azBlobstore.Mirror(context.Background(), &source, "stemcells", client.MirrorOptions{Delete: true})
+		Expect(err).ToNot(HaveOccurred())
+
+		report, err := azBlobstore.Mirror(context.Background(), &source, "stemcells", client.MirrorOptions{Delete: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Summary).To(Equal(client.TransferSummary{Skipped: 3}))
+	})
+
+	It("reports the blobs which could not be mirrored", func() {
+		storageClient.CopyFromURLStub = nil
+		storageClient.CopyFromURLReturns(errors.New("boom"))
+
+		report, err := azBlobstore.Mirror(context.Background(), &source, "stemcells", client.MirrorOptions{})
+		Expect(err).To(MatchError(ContainSubstring("failed to mirror 2 blobs")))
+		Expect(report.Failed).To(Equal(map[string]string{"changed": "boom", "missing": "boom"}))
+		Expect(report.Copied).To(BeEmpty())
+		Expect(report.Summary.Failed).To(Equal(int64(2)))
+	})
+})
+
+var _ = Describe("Mirror through the blob service", func() {
+	var (
+		sourceService *blobService
+		destService   *blobService
+		azBlobstore   client.AzBlobstore
+		source        client.AzBlobstore
+	)
+
+	BeforeEach(func() {
+		sourceService = newBlobService("source", "stemcells")
+		DeferCleanup(sourceService.Close)
+		destService = newBlobService("dest", "stemcells")
+		DeferCleanup(destService.Close)
+
+		sourceService.Put("ubuntu/same", "same")
+		sourceService.Put("ubuntu/changed", "new")
+		sourceService.Put("ubuntu/missing", "missing")
+		sourceService.Put("windows/ignored", "ignored")
+		destService.Put("ubuntu/same", "same")
+		destService.Put("ubuntu/changed", "old")
+		destService.Put("ubuntu/extra", "extra")
+
+		source = sourceService.Blobstore()
+		azBlobstore = destService.Blobstore()
+	})
+
+	It("copies between the accounts, verifies the copies and deletes extra blobs", func() {
+		report, err := azBlobstore.Mirror(context.Background(), &source, "ubuntu", client.MirrorOptions{Concurrency: 2, Delete: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report).To(Equal(client.MirrorReport{
+			Prefix:  "ubuntu/",
+			Summary: client.TransferSummary{Transferred: 2, Skipped: 1, Deleted: 1, Bytes: 10},
+			Copied:  []string{"changed", "missing"},
+			Deleted: []string{"extra"},
+			Failed:  map[string]string{},
+		}))
+		Expect(destService.Contents()).To(Equal(map[string]string{
+			"ubuntu/same":    "same",
+			"ubuntu/changed": "new",
+			"ubuntu/missing": "missing",
+		}))
+
+		report, err = azBlobstore.Mirror(context.Background(), &source, "ubuntu", client.MirrorOptions{Delete: true})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Summary).To(Equal(client.TransferSummary{Skipped: 3}))
+	})
+})
@@ -0,0 +1,242 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+)
+
+type MoveRecursiveOptions struct {
+	// Concurrency is the number of blobs moved at the same time.
+	Concurrency int
+	// ContinueOnError keeps moving the remaining blobs after a blob could
+	// not be moved. Otherwise no further blobs are started.
+	ContinueOnError bool
+}
+
+var errStopMoving = errors.New("stop moving")
+
+// Move copies src to dst, checks that the copy has the length and MD5 of
+// src and only then deletes src. src is kept if it changed in the meantime.
+func (client *AzBlobstore) Move(ctx context.Context, src string, dst string) error {
+	if err := checkBlobs("move", src, dst); err != nil {
+		return err
+	}
+
+	srcProps, err := client.storageClient.GetProperties(src)
+	if err != nil {
+		return err
+	}
+
+	return client.move(ctx, src, dst, srcProps)
+}
+
+// MoveRecursive moves every blob below srcPrefix that passes filter to the
+// same name below dstPrefix. Moving the same prefixes again resumes an
+// interrupted run: moved blobs are gone from srcPrefix, and blobs which were
+// copied but not yet deleted are not copied again. Blobs which could not be
+// moved are returned as BlobFailures.
+func (client *AzBlobstore) MoveRecursive(ctx context.Context, srcPrefix string, dstPrefix string, filter ListFilter, options MoveRecursiveOptions) error {
+	if err := checkPrefixes("move", srcPrefix, dstPrefix); err != nil {
+		return err
+	}
+
+	moved := startProgress("Moved", "blobs")
+
+	var mutex sync.Mutex
+	failures := &BlobFailures{Operation: "move", Errors: map[string]error{}}
+
+	_, err := client.List(srcPrefix, filter, ListOptions{}, func(items []*BlobItem) error {
+		forEachParallelContext(ctx, items, options.Concurrency, func(item *BlobItem) {
+			src := blobName(item)
+			err := client.move(ctx, src, rebaseName(src, srcPrefix, dstPrefix), itemProperties(item))
+			if err != nil {
+				mutex.Lock()
+				failures.Errors[src] = err
+				mutex.Unlock()
+				return
+			}
+			moved.add(1)
+		})
+
+		if len(failures.Errors) > 0 && !options.ContinueOnError {
+			return errStopMoving
+		}
+		return ctx.Err()
+	})
+	moved.stop()
+
+	if errors.Is(err, errStopMoving) {
+		log.Println("Stopped moving after the first failure")
+		err = nil
+	}
+	return failures.join(err)
+}
+
+// DryRunMove writes what Move would move to out without moving anything.
+func (client *AzBlobstore) DryRunMove(src string, dst string, out io.Writer) (DryRunSummary, error) {
+	if err := checkBlobs("move", src, dst); err != nil {
+		return DryRunSummary{}, err
+	}
+
+	preview := newDryRun("move", out)
+	_, err := client.storageClient.List(src, ListOptions{}, func(items []*BlobItem) error {
+		for _, item := range items {
+			if blobName(item) != src {
+				continue
+			}
+			if err := preview.addTarget(item, dst); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return DryRunSummary{}, err
+	}
+	return preview.finish()
+}
+
+// DryRunMoveRecursive writes what MoveRecursive would move to out without
+// moving anything.
+func (client *AzBlobstore) DryRunMoveRecursive(srcPrefix string, dstPrefix string, filter ListFilter, out io.Writer) (DryRunSummary, error) {
+	if err := checkPrefixes("move", srcPrefix, dstPrefix); err != nil {
+		return DryRunSummary{}, err
+	}
+
+	preview := newDryRun("move", out)
+	_, err := client.List(srcPrefix, filter, ListOptions{}, func(items []*BlobItem) error {
+		for _, item := range items {
+			if err := preview.addTarget(item, rebaseName(blobName(item), srcPrefix, dstPrefix)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return DryRunSummary{}, err
+	}
+	return preview.finish()
+}
+
+// move moves src, which had srcProps when it was looked up, to dst.
+func (client *AzBlobstore) move(ctx context.Context, src string, dst string, srcProps BlobProperties) error {
+	upToDate, err := client.upToDate(dst, srcProps)
+	if err != nil {
+		return err
+	}
+	if upToDate {
+		log.Printf("%s already matches %s, skipping the copy", dst, src)
+	} else {
+		err = client.storageClient.Copy(ctx, src, dst, CopyOptions{})
+		if err != nil {
+			return err
+		}
+
+		dstProps, err := client.storageClient.GetProperties(dst)
+		if err != nil {
+			return err
+		}
+		if !sameContent(srcProps, dstProps) {
+			return fmt.Errorf("copy %s (%d bytes, MD5 %x) does not match %s (%d bytes, MD5 %x), keeping the source",
+				dst, dstProps.ContentLength, dstProps.ContentMD5, src, srcProps.ContentLength, srcProps.ContentMD5)
+		}
+	}
+
+	err = client.storageClient.Delete(src, DeleteOptions{IfMatch: srcProps.ETag})
+	if bloberror.HasCode(err, bloberror.ConditionNotMet) {
+		return fmt.Errorf("%s changed while it was moved, keeping it: %w", src, err)
+	}
+	return err
+}
+
+// upToDate reports whether dst exists with the length and MD5 of a source
+// with srcProps, so that copying the source again can be skipped. Sources
+// without a Content-MD5 are never up to date.
+func (client *AzBlobstore) upToDate(dst string, srcProps BlobProperties) (bool, error) {
+	if len(srcProps.ContentMD5) == 0 {
+		return false, nil
+	}
+	dstProps, err := client.storageClient.GetProperties(dst)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return sameContent(srcProps, dstProps), nil
+}
+
+// sameContent reports whether dst has the length of src and, if src has a
+// Content-MD5, the same MD5.
+func sameContent(src BlobProperties, dst BlobProperties) bool {
+	if src.ContentLength != dst.ContentLength {
+		return false
+	}
+	return len(src.ContentMD5) == 0 || bytes.Equal(src.ContentMD5, dst.ContentMD5)
+}
+
+// checkPrefixes rejects copying or moving blobs of a container below
+// srcPrefix to dstPrefix if the copies could be listed as sources again.
+func checkPrefixes(action string, srcPrefix string, dstPrefix string) error {
+	if strings.HasPrefix(dstPrefix, srcPrefix) || strings.HasPrefix(srcPrefix, dstPrefix) {
+		return fmt.Errorf("cannot %s '%s' to '%s', the prefixes overlap", action, srcPrefix, dstPrefix)
+	}
+	return nil
+}
+
+// checkBlobs rejects copying or moving the blob src of a container onto
+// itself, which would leave no copy once the source is deleted.
+func checkBlobs(action string, src string, dst string) error {
+	if sameBlob(src, dst) {
+		return fmt.Errorf("cannot %s '%s' to '%s', they are the same blob", action, src, dst)
+	}
+	return nil
+}
+
+// sameBlob reports whether the names a and b address the same blob once
+// they are resolved as URL paths, which unescapes them and removes dot
+// segments and repeated slashes.
+func sameBlob(a string, b string) bool {
+	resolve := func(name string) string {
+		parsed, err := url.Parse("/" + name)
+		if err != nil {
+			return name
+		}
+		return path.Clean(parsed.Path)
+	}
+	return a == b || resolve(a) == resolve(b)
+}
+
+// rebaseName replaces the srcPrefix of name with dstPrefix.
+func rebaseName(name string, srcPrefix string, dstPrefix string) string {
+	return dstPrefix + strings.TrimPrefix(name, srcPrefix)
+}
+
+// itemProperties returns the properties of a listed blob.
+func itemProperties(item *BlobItem) BlobProperties {
+	var props BlobProperties
+	if item.Properties == nil {
+		return props
+	}
+	if item.Properties.ETag != nil {
+		props.ETag = strings.Trim(string(*item.Properties.ETag), `"`)
+	}
+	if item.Properties.LastModified != nil {
+		props.LastModified = *item.Properties.LastModified
+	}
+	if item.Properties.ContentLength != nil {
+		props.ContentLength = *item.Properties.ContentLength
+	}
+	props.ContentMD5 = item.Properties.ContentMD5
+	return props
+}
@@ -0,0 +1,178 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	azContainer "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client"
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client/clientfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Move", func() {
+	var (
+		storageClient *clientfakes.FakeStorageClient
+		azBlobstore   client.AzBlobstore
+		blobs         map[string]client.BlobProperties
+		mutex         sync.Mutex
+	)
+
+	notFound := &azcore.ResponseError{ErrorCode: string(bloberror.BlobNotFound), StatusCode: 404}
+
+	movableItem := func(name string) *client.BlobItem {
+		props := blobs[name]
+		etag := azcore.ETag(`"` + props.ETag + `"`)
+		return &client.BlobItem{
+			Name: &name,
+			Properties: &azContainer.BlobProperties{
+				ETag:          &etag,
+				ContentLength: &props.ContentLength,
+				ContentMD5:    props.ContentMD5,
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		blobs = map[string]client.BlobProperties{
+			"old/a":   {ETag: "0x1", ContentLength: 3, ContentMD5: []byte{1}},
+			"old/b/c": {ETag: "0x2", ContentLength: 5, ContentMD5: []byte{2}},
+		}
+
+		storageClient = &clientfakes.FakeStorageClient{}
+		storageClient.GetPropertiesStub = func(name string) (client.BlobProperties, error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			props, ok := blobs[name]
+			if !ok {
+				return client.BlobProperties{}, notFound
+			}
+			return props, nil
+		}
+		storageClient.CopyStub = func(_ context.Context, src string, dst string, _ client.CopyOptions) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			props := blobs[src]
+			props.ETag = "0xcopy"
+			blobs[dst] = props
+			return nil
+		}
+
+		azBlobstore, _ = client.New(storageClient) //nolint:errcheck
+	})
+
+	It("copies the blob and deletes the source if it has not changed", func() {
+		Expect(azBlobstore.Move(context.Background(), "old/a", "new/a")).To(Succeed())
+
+		_, src, dst, _ := storageClient.CopyArgsForCall(0)
+		Expect(src).To(Equal("old/a"))
+		Expect(dst).To(Equal("new/a"))
+
+		Expect(storageClient.DeleteCallCount()).To(Equal(1))
+		name, options := storageClient.DeleteArgsForCall(0)
+		Expect(name).To(Equal("old/a"))
+		Expect(options.IfMatch).To(Equal("0x1"))
+	})
+
+	It("keeps the source if the copy does not match it", func() {
+		storageClient.CopyStub = func(_ context.Context, src string, dst string, _ client.CopyOptions) error {
+			blobs[dst] = client.BlobProperties{ContentLength: 3, ContentMD5: []byte{9}}
+			return nil
+		}
+
+		err := azBlobstore.Move(context.Background(), "old/a", "new/a")
+		Expect(err).To(MatchError(ContainSubstring("copy new/a (3 bytes, MD5 09) does not match old/a (3 bytes, MD5 01), keeping the source")))
+		Expect(storageClient.DeleteCallCount()).To(Equal(0))
+	})
+
+	It("keeps the source if the copy failed", func() {
+		storageClient.CopyReturns(errors.New("copy failed or aborted with status: failed"))
+
+		Expect(azBlobstore.Move(context.Background(), "old/a", "new/a")).To(MatchError("copy failed or aborted with status: failed"))
+		Expect(storageClient.DeleteCallCount()).To(Equal(0))
+	})
+
+	It("reports a source which changed during the move", func() {
+		storageClient.DeleteReturns(&azcore.ResponseError{ErrorCode: string(bloberror.ConditionNotMet), StatusCode: 412})
+
+		err := azBlobstore.Move(context.Background(), "old/a", "new/a")
+		Expect(err).To(MatchError(ContainSubstring("old/a changed while it was moved, keeping it")))
+	})
+
+	It("skips copying a destination which already matches the source", func() {
+		blobs["new/a"] = client.BlobProperties{ETag: "0x3", ContentLength: 3, ContentMD5: []byte{1}}
+
+		Expect(azBlobstore.Move(context.Background(), "old/a", "new/a")).To(Succeed())
+		Expect(storageClient.CopyCallCount()).To(Equal(0))
+		Expect(storageClient.DeleteCallCount()).To(Equal(1))
+	})
+
+	It("refuses to move a blob onto itself", func() {
+		for _, dst := range []string{"old/a", "old/./a", "old//a", "old/%61"} {
+			err := azBlobstore.Move(context.Background(), "old/a", dst)
+			Expect(err).To(MatchError(fmt.Sprintf("cannot move 'old/a' to '%s', they are the same blob", dst)))
+
+			_, err = azBlobstore.DryRunMove("old/a", dst, &bytes.Buffer{})
+			Expect(err).To(HaveOccurred())
+		}
+
+		Expect(storageClient.CopyCallCount()).To(Equal(0))
+		Expect(storageClient.DeleteCallCount()).To(Equal(0))
+		Expect(blobs).To(HaveKey("old/a"))
+	})
+
+	Context("recursive", func() {
+		BeforeEach(func() {
+			storageClient.ListStub = listPages([]*client.BlobItem{movableItem("old/a"), movableItem("old/b/c")})
+		})
+
+		It("moves every blob to the same name below the destination prefix", func() {
+			err := azBlobstore.MoveRecursive(context.Background(), "old/", "new/", client.ListFilter{}, client.MoveRecursiveOptions{Concurrency: 2})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(blobs).To(HaveKey("new/a"))
+			Expect(blobs).To(HaveKey("new/b/c"))
+			Expect(storageClient.DeleteCallCount()).To(Equal(2))
+		})
+
+		It("reports the blobs which could not be moved", func() {
+			storageClient.CopyStub = func(_ context.Context, src string, dst string, _ client.CopyOptions) error {
+				return errors.New("boom")
+			}
+
+			err := azBlobstore.MoveRecursive(context.Background(), "old/", "new/", client.ListFilter{}, client.MoveRecursiveOptions{})
+			var failures *client.BlobFailures
+			Expect(errors.As(err, &failures)).To(BeTrue())
+			Expect(failures.Errors).To(HaveLen(2))
+			Expect(failures.Errors).To(HaveKey("old/a"))
+		})
+
+		It("rejects overlapping prefixes", func() {
+			err := azBlobstore.MoveRecursive(context.Background(), "old/", "old/new/", client.ListFilter{}, client.MoveRecursiveOptions{})
+			Expect(err).To(MatchError("cannot move 'old/' to 'old/new/', the prefixes overlap"))
+			Expect(storageClient.ListCallCount()).To(Equal(0))
+		})
+
+		It("prints what would be moved in a dry run", func() {
+			output := &bytes.Buffer{}
+
+			summary, err := azBlobstore.DryRunMoveRecursive("old/", "new/", client.ListFilter{}, output)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summary).To(Equal(client.DryRunSummary{Count: 2, Bytes: 8}))
+			Expect(output.String()).To(Equal(
+				"would move old/a to new/a (3 bytes)\n" +
+					"would move old/b/c to new/b/c (5 bytes)\n" +
+					"would move 2 blobs (8 bytes)\n",
+			))
+			Expect(storageClient.CopyCallCount()).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"sync"
+)
+
+// forEachParallel calls fn for every item using at most concurrency
+// goroutines and returns once all calls have finished.
+func forEachParallel[T any](items []T, concurrency int, fn func(T)) {
+	forEachParallelContext(context.Background(), items, concurrency, fn)
+}
+
+// forEachParallelContext is forEachParallel, but does not start fn for
+// further items once ctx is done.
+func forEachParallelContext[T any](ctx context.Context, items []T, concurrency int, fn func(T)) {
+	work := make(chan T)
+
+	var wg sync.WaitGroup
+	for range max(concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range work {
+				if ctx.Err() == nil {
+					fn(item)
+				}
+			}
+		}()
+	}
+
+	for _, item := range items {
+		select {
+		case work <- item:
+		case <-ctx.Done():
+		}
+	}
+	close(work)
+	wg.Wait()
+}
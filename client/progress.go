@@ -0,0 +1,67 @@
+package client
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+const progressInterval = 10 * time.Second
+
+// progress periodically logs how many items of a long running bulk
+// operation have been processed and at which rate.
+type progress struct {
+	action  string
+	unit    string
+	started time.Time
+	count   atomic.Int64
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func startProgress(action string, unit string) *progress {
+	p := &progress{
+		action:  action,
+		unit:    unit,
+		started: time.Now(),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(p.stopped)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.log()
+			case <-p.done:
+				return
+			}
+		}
+	}()
+
+	return p
+}
+
+func (p *progress) add(n int) {
+	p.count.Add(int64(n))
+}
+
+// stop ends the periodic logging and logs the final count.
+func (p *progress) stop() {
+	close(p.done)
+	<-p.stopped
+	p.log()
+}
+
+func (p *progress) log() {
+	count := p.count.Load()
+	elapsed := time.Since(p.started).Seconds()
+	rate := 0.0
+	if elapsed > 0 {
+		rate = float64(count) / elapsed
+	}
+	log.Printf("%s %d %s (%.1f %s/s)\n", p.action, count, p.unit, rate, p.unit)
+}
@@ -0,0 +1,28 @@
+package client
+
+type PutRecursiveOptions struct {
+	// Concurrency is the number of files uploaded at the same time.
+	Concurrency int
+	// Exclude skips files whose path relative to the directory matches one
+	// of these patterns, using the rules of ListFilter.Exclude.
+	Exclude []string
+}
+
+// PutRecursive uploads every file below dir to its relative path below
+// prefix, verifying the MD5 of each upload like Put. Files which could not
+// be uploaded are returned as BlobFailures.
+func (client *AzBlobstore) PutRecursive(dir string, prefix string, options PutRecursiveOptions) (TransferSummary, error) {
+	prefix = directoryPrefix(prefix)
+
+	files, err := walkLocal(dir, options.Exclude)
+	if err != nil {
+		return TransferSummary{}, err
+	}
+
+	tally := newTransferTally("upload", "Uploaded")
+	forEachParallel(files, options.Concurrency, func(file localFile) {
+		err := client.Put(file.Path, prefix+file.Name, UploadOptions{})
+		tally.add(file.Name, file.Size, false, err)
+	})
+	return tally.finish(nil)
+}
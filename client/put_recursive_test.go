@@ -0,0 +1,73 @@
+package client_test
+
+import (
+	"crypto/md5"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client"
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client/clientfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PutRecursive", func() {
+	var (
+		storageClient *clientfakes.FakeStorageClient
+		azBlobstore   client.AzBlobstore
+		dir           string
+		uploaded      map[string]string
+		mutex         sync.Mutex
+	)
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		Expect(os.MkdirAll(filepath.Join(dir, "linux", "x86"), 0o755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "index"), []byte("index"), 0o644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "linux", "x86", "package.tgz"), []byte("package"), 0o644)).To(Succeed())
+
+		uploaded = map[string]string{}
+		storageClient = &clientfakes.FakeStorageClient{}
+		storageClient.UploadStub = func(source io.ReadSeekCloser, name string, _ client.UploadOptions) ([]byte, error) {
+			content, err := io.ReadAll(source)
+			if err != nil {
+				return nil, err
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			uploaded[name] = string(content)
+			sum := md5.Sum(content)
+			return sum[:], nil
+		}
+		azBlobstore, _ = client.New(storageClient) //nolint:errcheck
+	})
+
+	It("uploads every file to its relative path below the prefix", func() {
+		summary, err := azBlobstore.PutRecursive(dir, "packages", client.PutRecursiveOptions{Concurrency: 2})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary).To(Equal(client.TransferSummary{Transferred: 2, Bytes: 12}))
+
+		Expect(uploaded).To(Equal(map[string]string{
+			"packages/index":                 "index",
+			"packages/linux/x86/package.tgz": "package",
+		}))
+	})
+
+	It("reports the files whose upload did not match their MD5", func() {
+		storageClient.UploadStub = func(_ io.ReadSeekCloser, name string, _ client.UploadOptions) ([]byte, error) {
+			if name == "packages/index" {
+				return []byte{1, 2, 3}, nil
+			}
+			return nil, errors.New("boom")
+		}
+
+		summary, err := azBlobstore.PutRecursive(dir, "packages/", client.PutRecursiveOptions{})
+		Expect(summary).To(Equal(client.TransferSummary{Failed: 2}))
+		Expect(err).To(MatchError(ContainSubstring("failed to upload 2 blobs")))
+		Expect(err).To(MatchError(ContainSubstring("index: the upload responded an MD5 [1 2 3] does not match the source file MD5")))
+	})
+})
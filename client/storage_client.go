@@ -1,24 +1,38 @@
 package client
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
+	"hash/crc64"
 	"io"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/streaming"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	azBlob "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
 	azContainer "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/lease"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 
 	"github.com/cloudfoundry/bosh-azure-storage-cli/config"
@@ -29,26 +43,90 @@ type StorageClient interface {
 	Upload(
 		source io.ReadSeekCloser,
 		dest string,
+		options UploadOptions,
 	) ([]byte, error)
 
 	Download(
 		source string,
 		dest *os.File,
+		options DownloadOptions,
 	) error
 
+	DownloadStream(
+		source string,
+	) (io.ReadCloser, error)
+
 	Copy(
+		ctx context.Context,
 		srcBlob string,
 		destBlob string,
+		options CopyOptions,
+	) error
+
+	CopyFromURL(
+		ctx context.Context,
+		srcURL string,
+		destBlob string,
+		options CopyOptions,
 	) error
 
 	Delete(
 		dest string,
+		options DeleteOptions,
+	) error
+
+	DeleteBatch(
+		names []string,
+	) (map[string]error, error)
+
+	Undelete(
+		dest string,
+	) error
+
+	CreateSnapshot(
+		dest string,
+	) (string, error)
+
+	PromoteSnapshot(
+		ctx context.Context,
+		dest string,
+		snapshot string,
 	) error
 
-	DeleteRecursive(
+	RestoreVersion(
+		ctx context.Context,
 		dest string,
+		versionID string,
 	) error
 
+	AcquireLease(
+		dest string,
+		duration int32,
+		proposedID string,
+	) (string, error)
+
+	RenewLease(
+		ctx context.Context,
+		dest string,
+		leaseID string,
+	) error
+
+	ReleaseLease(
+		dest string,
+		leaseID string,
+	) error
+
+	BreakLease(
+		dest string,
+		breakPeriod int32,
+	) (int32, error)
+
+	ChangeLease(
+		dest string,
+		leaseID string,
+		proposedID string,
+	) (string, error)
+
 	Exists(
 		dest string,
 	) (bool, error)
@@ -59,15 +137,121 @@ type StorageClient interface {
 		expiration time.Duration,
 	) (string, error)
 
+	CopySourceURL(
+		source string,
+		expiration time.Duration,
+	) (string, error)
+
+	ContainerURL() string
+
 	List(
 		prefix string,
-	) ([]string, error)
+		options ListOptions,
+		handlePage func(items []*BlobItem) error,
+	) (string, error)
 	Properties(
 		dest string,
 	) error
+
+	GetProperties(
+		dest string,
+	) (BlobProperties, error)
 	EnsureContainerExists() error
 }
 
+type UploadOptions struct {
+	// LeaseID is required to overwrite a blob with an active lease.
+	LeaseID string
+	// CRC64 uploads the source in blocks which the service validates
+	// against their CRC64, instead of in a single request. The MD5 of the
+	// content is computed while it is uploaded and stored as the
+	// Content-MD5 of the blob. As the service does not compute an MD5 of
+	// the committed blob, Upload returns no MD5 to check.
+	CRC64 bool
+	// SHA256 stores the hex encoded SHA-256 of the content in the sha256
+	// metadata of the blob instead of its Content-MD5, without using MD5.
+	// The source is uploaded in blocks as with CRC64.
+	SHA256 bool
+}
+
+type DownloadOptions struct {
+	// Snapshot downloads the snapshot with this timestamp instead of the blob.
+	Snapshot string
+	// VersionID downloads this previous version instead of the current blob.
+	VersionID string
+	// CRC64 downloads the blob in ranges which are validated against the
+	// CRC64 the service computes for them, and verifies the content against
+	// the Content-MD5 of the blob.
+	CRC64 bool
+	// SHA256 verifies the content against the sha256 metadata of the blob
+	// instead of its Content-MD5. The blob is downloaded in ranges as with
+	// CRC64.
+	SHA256 bool
+}
+
+const (
+	// uploadBlockSize is the size of the blocks uploaded with CRC64 or
+	// SHA-256 integrity options.
+	uploadBlockSize = 8 * 1024 * 1024
+	// downloadRangeSize is the largest range the service computes a CRC64
+	// for.
+	downloadRangeSize = 4 * 1024 * 1024
+)
+
+// CopyOptions sets properties of a copy instead of taking them over from
+// the source.
+type CopyOptions struct {
+	// Metadata replaces the metadata of the source when not empty.
+	Metadata map[string]string
+	// Tags are set on the copy. Tags of the source are never copied.
+	Tags map[string]string
+	// Tier is the access tier of the copy, e.g. Hot, Cool or Archive.
+	Tier string
+	// Headers replace the content headers of the copy which are not empty.
+	Headers ContentHeaders
+	// VerifyMD5 fails the copy unless the MD5 of the copy matches the
+	// Content-MD5 of the source. Copies of syncCopyMaxSize or more are
+	// downloaded to hash them.
+	VerifyMD5 bool
+}
+
+type ContentHeaders struct {
+	ContentType        string
+	ContentEncoding    string
+	ContentLanguage    string
+	ContentDisposition string
+	CacheControl       string
+}
+
+// syncCopyMaxSize is the largest blob Copy From URL can copy in one request.
+const syncCopyMaxSize = 256 * 1024 * 1024
+
+// copySourceExpiration bounds how long the service may read the source of a
+// copy through a signed URL. It has to outlast the copy itself.
+const copySourceExpiration = 7 * 24 * time.Hour
+
+type DeleteOptions struct {
+	// Snapshots controls the snapshots of the blob: "include" deletes the blob
+	// together with its snapshots and "only" deletes just its snapshots.
+	// If empty, deleting a blob which has snapshots fails.
+	Snapshots string
+	// LeaseID is required to delete a blob with an active lease.
+	LeaseID string
+	// IfMatch only deletes the blob while it still has this ETag.
+	IfMatch string
+}
+
+// leaseConditions returns the access conditions for operations on a blob
+// with an active lease, or nil if no lease ID is given.
+func leaseConditions(leaseID string) *azBlob.AccessConditions {
+	if leaseID == "" {
+		return nil
+	}
+	return &azBlob.AccessConditions{
+		LeaseAccessConditions: &azBlob.LeaseAccessConditions{LeaseID: &leaseID},
+	}
+}
+
 type DefaultStorageClient struct {
 	credential    *azblob.SharedKeyCredential
 	serviceURL    string
@@ -80,7 +264,7 @@ func NewStorageClient(storageConfig config.AZStorageConfig) (StorageClient, erro
 		return nil, err
 	}
 
-	serviceURL := fmt.Sprintf("https://%s.%s/%s", storageConfig.AccountName, storageConfig.StorageEndpoint(), storageConfig.ContainerName)
+	serviceURL := fmt.Sprintf("%s/%s", storageConfig.AccountURL(), storageConfig.ContainerName)
 
 	return DefaultStorageClient{credential: credential, serviceURL: serviceURL, storageConfig: storageConfig}, nil
 }
@@ -88,6 +272,7 @@ func NewStorageClient(storageConfig config.AZStorageConfig) (StorageClient, erro
 func (dsc DefaultStorageClient) Upload(
 	source io.ReadSeekCloser,
 	dest string,
+	options UploadOptions,
 ) ([]byte, error) {
 	blobURL := fmt.Sprintf("%s/%s", dsc.serviceURL, dest)
 
@@ -117,19 +302,90 @@ func (dsc DefaultStorageClient) Upload(
 	if err != nil {
 		return nil, err
 	}
-	uploadResponse, err := client.Upload(ctx, source, nil)
+
+	var contentMD5 []byte
+	if options.CRC64 || options.SHA256 {
+		err = uploadBlocks(ctx, client, source, options)
+	} else {
+		var uploadResponse blockblob.UploadResponse
+		uploadResponse, err = client.Upload(ctx, source, &blockblob.UploadOptions{
+			AccessConditions: leaseConditions(options.LeaseID),
+		})
+		contentMD5 = uploadResponse.ContentMD5
+	}
 	if err != nil {
 		if dsc.storageConfig.Timeout != "" && errors.Is(err, context.DeadlineExceeded) {
 			return nil, fmt.Errorf("upload failed: timeout of %s reached while uploading %s", dsc.storageConfig.Timeout, dest)
 		}
 		return nil, fmt.Errorf("upload failure: %w", err)
 	}
-	return uploadResponse.ContentMD5, err
+	return contentMD5, nil
+}
+
+// uploadBlocks uploads source in blocks of uploadBlockSize, each validated
+// by the service against its CRC64, and commits them with the MD5 of the
+// content, or with options.SHA256 its SHA-256, hashed in the same pass.
+func uploadBlocks(ctx context.Context, client *blockblob.Client, source io.Reader, options UploadOptions) error {
+	// MD5 is not computed with options.SHA256, as it must not be used where
+	// only FIPS approved algorithms are allowed.
+	var contentHash hash.Hash
+	if options.SHA256 {
+		contentHash = sha256.New()
+	} else {
+		contentHash = md5.New()
+	}
+
+	var leaseAccess *azBlob.LeaseAccessConditions
+	if conditions := leaseConditions(options.LeaseID); conditions != nil {
+		leaseAccess = conditions.LeaseAccessConditions
+	}
+
+	var blockIDs []string
+	block := make([]byte, uploadBlockSize)
+	for {
+		n, readErr := io.ReadFull(source, block)
+		if n > 0 {
+			contentHash.Write(block[:n]) //nolint:errcheck
+
+			// Block IDs must all have the same length.
+			blockID := base64.StdEncoding.EncodeToString(fmt.Appendf(nil, "%08d", len(blockIDs)))
+			_, err := client.StageBlock(ctx, blockID, streaming.NopCloser(bytes.NewReader(block[:n])), &blockblob.StageBlockOptions{
+				LeaseAccessConditions:   leaseAccess,
+				TransactionalValidation: azBlob.TransferValidationTypeCRC64(crc64.Checksum(block[:n], crc64Table)),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to upload block %d: %w", len(blockIDs), err)
+			}
+			blockIDs = append(blockIDs, blockID)
+		}
+		if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+
+	commitOptions := &blockblob.CommitBlockListOptions{
+		AccessConditions: leaseConditions(options.LeaseID),
+	}
+	if options.SHA256 {
+		commitOptions.Metadata = map[string]*string{sha256MetadataKey: to.Ptr(hex.EncodeToString(contentHash.Sum(nil)))}
+	} else {
+		commitOptions.HTTPHeaders = &azBlob.HTTPHeaders{BlobContentMD5: contentHash.Sum(nil)}
+	}
+	_, err := client.CommitBlockList(ctx, blockIDs, commitOptions)
+	if err != nil {
+		return fmt.Errorf("failed to commit %d blocks: %w", len(blockIDs), err)
+	}
+	log.Printf("Uploaded %d blocks validated by CRC64", len(blockIDs))
+	return nil
 }
 
 func (dsc DefaultStorageClient) Download(
 	source string,
 	dest *os.File,
+	options DownloadOptions,
 ) error {
 
 	blobURL := fmt.Sprintf("%s/%s", dsc.serviceURL, source)
@@ -140,6 +396,28 @@ func (dsc DefaultStorageClient) Download(
 		return err
 	}
 
+	if options.Snapshot != "" && options.VersionID != "" {
+		return errors.New("cannot download a snapshot and a version at the same time")
+	}
+	if options.Snapshot != "" {
+		log.Printf("Using snapshot %s", options.Snapshot)
+		client, err = client.WithSnapshot(options.Snapshot)
+		if err != nil {
+			return err
+		}
+	}
+	if options.VersionID != "" {
+		log.Printf("Using version %s", options.VersionID)
+		client, err = client.WithVersionID(options.VersionID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if options.CRC64 || options.SHA256 {
+		return downloadRanges(context.Background(), client.BlobClient(), dest, options)
+	}
+
 	blobSize, err := client.DownloadFile(context.Background(), dest, nil) //nolint:ineffassign,staticcheck
 	if err != nil {
 		return err
@@ -156,52 +434,476 @@ func (dsc DefaultStorageClient) Download(
 	return nil
 }
 
+// downloadStreamRetries is how often a stream returned by DownloadStream
+// resumes reading after a failed read.
+const downloadStreamRetries = 5
+
+// DownloadStream returns the content of the blob source as a stream. A read
+// which fails is resumed with a range request from the failed offset, which
+// only succeeds while the blob still has the ETag it had when the stream
+// was opened.
+func (dsc DefaultStorageClient) DownloadStream(
+	source string,
+) (io.ReadCloser, error) {
+
+	blobURL := fmt.Sprintf("%s/%s", dsc.serviceURL, source)
+
+	log.Println(fmt.Sprintf("Downloading %s", blobURL)) //nolint:staticcheck
+	client, err := azBlob.NewClientWithSharedKeyCredential(blobURL, dsc.credential, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	resp, err := client.DownloadStream(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.NewRetryReader(ctx, &azBlob.RetryReaderOptions{MaxRetries: downloadStreamRetries}), nil
+}
+
+// downloadRanges writes the blob of client to dest in ranges of
+// downloadRangeSize, each validated against the CRC64 the service computes
+// for it with options.CRC64. The content is hashed while it is written and
+// verified against the sha256 metadata with options.SHA256, or against the
+// Content-MD5 otherwise.
+func downloadRanges(ctx context.Context, client *azBlob.Client, dest io.Writer, options DownloadOptions) error {
+	props, err := client.GetProperties(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var checksum string
+	var expected []byte
+	var contentHash hash.Hash
+	if options.SHA256 {
+		value := metadataValue(props.Metadata, sha256MetadataKey)
+		if value == "" {
+			return errors.New("the blob has no sha256 metadata to verify the download against")
+		}
+		expected, err = hex.DecodeString(value)
+		if err != nil {
+			return fmt.Errorf("invalid sha256 metadata '%s': %w", value, err)
+		}
+		checksum, contentHash = "SHA-256", sha256.New()
+	} else {
+		checksum, expected, contentHash = "MD5", props.ContentMD5, md5.New()
+		if len(expected) == 0 {
+			log.Println("The blob has no Content-MD5, only the ranges of the download are validated")
+		}
+	}
+
+	rangeCtx := ctx
+	if options.CRC64 {
+		rangeCtx = policy.WithHTTPHeader(ctx, http.Header{"x-ms-range-get-content-crc64": []string{"true"}})
+	}
+	// Every range has to come from the same content.
+	conditions := &azBlob.AccessConditions{ModifiedAccessConditions: &azBlob.ModifiedAccessConditions{IfMatch: props.ETag}}
+
+	size := *props.ContentLength
+	for offset := int64(0); offset < size; offset += downloadRangeSize {
+		response, err := client.DownloadStream(rangeCtx, &azBlob.DownloadStreamOptions{
+			Range:            azBlob.HTTPRange{Offset: offset, Count: downloadRangeSize},
+			AccessConditions: conditions,
+		})
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(response.Body)
+		response.Body.Close() //nolint:errcheck
+		if err != nil {
+			return fmt.Errorf("failed to download the range at %d: %w", offset, err)
+		}
+
+		if options.CRC64 {
+			if len(response.ContentCRC64) != 8 || binary.LittleEndian.Uint64(response.ContentCRC64) != crc64.Checksum(data, crc64Table) {
+				return fmt.Errorf("the range at %d does not match its CRC64 %x", offset, response.ContentCRC64)
+			}
+		}
+
+		contentHash.Write(data) //nolint:errcheck
+		_, err = dest.Write(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(expected) > 0 {
+		actual := contentHash.Sum(nil)
+		if !bytes.Equal(actual, expected) {
+			return fmt.Errorf("the downloaded %s %x does not match the blob %s %x", checksum, actual, checksum, expected)
+		}
+		log.Printf("Download %s matches the blob", checksum)
+	}
+	return nil
+}
+
+// metadataValue looks up key in metadata ignoring its case, as the service
+// may return metadata keys with another case than they were set with.
+func metadataValue(metadata map[string]*string, key string) string {
+	for name, value := range metadata {
+		if strings.EqualFold(name, key) && value != nil {
+			return *value
+		}
+	}
+	return ""
+}
+
 func (dsc DefaultStorageClient) Copy(
+	ctx context.Context,
 	srcBlob string,
 	destBlob string,
+	options CopyOptions,
 ) error {
 	log.Printf("Copying blob from %s to %s", srcBlob, destBlob)
 
-	srcURL := fmt.Sprintf("%s/%s", dsc.serviceURL, srcBlob)
+	// The synchronous copy cannot authorize the source with the shared key,
+	// not even within the same account.
+	srcURL, err := dsc.CopySourceURL(srcBlob, copySourceExpiration)
+	if err != nil {
+		return err
+	}
+	return dsc.copyBlob(ctx, srcURL, destBlob, options)
+}
+
+func (dsc DefaultStorageClient) CopyFromURL(
+	ctx context.Context,
+	srcURL string,
+	destBlob string,
+	options CopyOptions,
+) error {
+	// Only log the blob, the query of a signed URL holds its signature.
+	log.Printf("Copying blob from %s to %s", strings.SplitN(srcURL, "?", 2)[0], destBlob)
+
+	return dsc.copyBlob(ctx, srcURL, destBlob, options)
+}
+
+// copyBlob copies srcURL onto destBlob, synchronously for blobs smaller than
+// syncCopyMaxSize and as a polled server-side copy otherwise, and then
+// applies the content headers and verification of options.
+func (dsc DefaultStorageClient) copyBlob(
+	ctx context.Context,
+	srcURL string,
+	destBlob string,
+	options CopyOptions,
+) error {
 	destURL := fmt.Sprintf("%s/%s", dsc.serviceURL, destBlob)
 
+	ctx, cancel, err := dsc.copyContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
 	destClient, err := blockblob.NewClientWithSharedKeyCredential(destURL, dsc.credential, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create destination client: %w", err)
 	}
 
-	resp, err := destClient.StartCopyFromURL(context.Background(), srcURL, nil)
+	var srcProps azBlob.GetPropertiesResponse
+	srcClient, err := azBlob.NewClientWithNoCredential(srcURL, nil)
+	if err == nil {
+		srcProps, err = srcClient.GetProperties(ctx, nil)
+	}
+	if err != nil {
+		if options.VerifyMD5 {
+			return fmt.Errorf("failed to get properties of copy source: %w", err)
+		}
+		log.Printf("Failed to get the size of the copy source, copying asynchronously: %s", err)
+	}
+
+	var srcMD5 []byte
+	if options.VerifyMD5 {
+		srcMD5 = srcProps.ContentMD5
+		if len(srcMD5) == 0 {
+			return errors.New("copy source has no Content-MD5 to verify the copy against")
+		}
+	}
+
+	sync := srcProps.ContentLength != nil && *srcProps.ContentLength < syncCopyMaxSize
+	if sync {
+		err = dsc.syncCopy(ctx, destClient, srcURL, srcMD5, options)
+	} else {
+		err = dsc.asyncCopy(ctx, destClient, srcURL, options)
+	}
+	if err != nil {
+		return err
+	}
+
+	if options.Headers != (ContentHeaders{}) {
+		err = setContentHeaders(ctx, destClient, options.Headers)
+		if err != nil {
+			return err
+		}
+	}
+
+	if options.VerifyMD5 {
+		destMD5, err := copiedMD5(ctx, destClient, sync)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(destMD5, srcMD5) {
+			return fmt.Errorf("the copy MD5 %v does not match the source MD5 %v", destMD5, srcMD5)
+		}
+		log.Println("Copy MD5 matches the source")
+	}
+
+	return nil
+}
+
+// copiedMD5 returns the MD5 of the copy of destClient. A synchronous copy
+// was verified by the service, so its Content-MD5 can be trusted. A
+// server-side copy takes the Content-MD5 over from the source without
+// checking it, so the copied content is downloaded and hashed instead.
+func copiedMD5(ctx context.Context, destClient *blockblob.Client, sync bool) ([]byte, error) {
+	if sync {
+		props, err := destClient.GetProperties(ctx, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get properties of copy destination: %w", err)
+		}
+		return props.ContentMD5, nil
+	}
+
+	log.Println("Hashing the copy to verify it")
+	resp, err := destClient.DownloadStream(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download copy destination: %w", err)
+	}
+	body := resp.NewRetryReader(ctx, &azBlob.RetryReaderOptions{MaxRetries: downloadStreamRetries})
+	defer body.Close() //nolint:errcheck
+
+	hash := md5.New()
+	_, err = io.Copy(hash, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download copy destination: %w", err)
+	}
+	return hash.Sum(nil), nil
+}
+
+// copyFromURL copies srcURL onto destBlob with a polled server-side copy.
+// It is used for sources which can only be authorized by the shared key,
+// like snapshots and versions of blobs in the same container.
+func (dsc DefaultStorageClient) copyFromURL(
+	ctx context.Context,
+	srcURL string,
+	destBlob string,
+) error {
+	destURL := fmt.Sprintf("%s/%s", dsc.serviceURL, destBlob)
+
+	ctx, cancel, err := dsc.copyContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	destClient, err := blockblob.NewClientWithSharedKeyCredential(destURL, dsc.credential, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create destination client: %w", err)
+	}
+
+	return dsc.asyncCopy(ctx, destClient, srcURL, CopyOptions{})
+}
+
+// copyContext is cancelled when ctx is done or the configured copy timeout
+// is reached.
+func (dsc DefaultStorageClient) copyContext(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	if dsc.storageConfig.CopyTimeout == "" {
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, cancel, nil
+	}
+
+	timeoutInt, err := strconv.Atoi(dsc.storageConfig.CopyTimeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid copy timeout format \"%s\", need \"<seconds in number>\" e.g. 3600: %w", dsc.storageConfig.CopyTimeout, err)
+	}
+	if timeoutInt < 1 {
+		return nil, nil, fmt.Errorf("invalid copy timeout \"%s\", need at least 1 second", dsc.storageConfig.CopyTimeout)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutInt)*time.Second)
+	return ctx, cancel, nil
+}
+
+// syncCopy copies srcURL with a single Copy From URL request. When srcMD5
+// is given, the service verifies the copied content against it.
+func (dsc DefaultStorageClient) syncCopy(
+	ctx context.Context,
+	destClient *blockblob.Client,
+	srcURL string,
+	srcMD5 []byte,
+	options CopyOptions,
+) error {
+	log.Println("Copying synchronously")
+
+	_, err := destClient.CopyFromURL(ctx, srcURL, &azBlob.CopyFromURLOptions{
+		Metadata:         metadataOption(options.Metadata),
+		BlobTags:         options.Tags,
+		Tier:             tierOption(options.Tier),
+		SourceContentMD5: srcMD5,
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return dsc.copyCancelled(ctx.Err())
+		}
+		return fmt.Errorf("failed to copy: %w", err)
+	}
+
+	log.Println("Copy completed successfully")
+	return nil
+}
+
+const (
+	copyPollMinInterval = 200 * time.Millisecond
+	copyPollMaxInterval = 10 * time.Second
+)
+
+// asyncCopy starts a server-side copy of srcURL and waits for it to
+// complete. The pending copy is aborted when ctx is cancelled, so that it
+// does not keep running on the service.
+func (dsc DefaultStorageClient) asyncCopy(
+	ctx context.Context,
+	destClient *blockblob.Client,
+	srcURL string,
+	options CopyOptions,
+) error {
+	resp, err := destClient.StartCopyFromURL(ctx, srcURL, &azBlob.StartCopyFromURLOptions{
+		Metadata: metadataOption(options.Metadata),
+		BlobTags: options.Tags,
+		Tier:     tierOption(options.Tier),
+	})
 	if err != nil {
+		if ctx.Err() != nil {
+			return dsc.copyCancelled(ctx.Err())
+		}
 		return fmt.Errorf("failed to start copy: %w", err)
 	}
 
 	copyID := *resp.CopyID
 	log.Printf("Copy started with CopyID: %s", copyID)
 
-	// Wait for completion
-	for {
-		props, err := destClient.GetProperties(context.Background(), nil)
+	status := azBlob.CopyStatusType("")
+	if resp.CopyStatus != nil {
+		status = *resp.CopyStatus
+	}
+
+	interval := copyPollMinInterval
+	lastProgress := ""
+	for status != azBlob.CopyStatusTypeSuccess {
+		if status != "" && status != azBlob.CopyStatusTypePending {
+			return fmt.Errorf("copy failed or aborted with status: %s", status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return dsc.abortCopy(destClient, copyID, ctx.Err())
+		case <-time.After(interval):
+		}
+		interval = nextCopyPollInterval(interval)
+
+		props, err := destClient.GetProperties(ctx, nil)
 		if err != nil {
+			if ctx.Err() != nil {
+				return dsc.abortCopy(destClient, copyID, ctx.Err())
+			}
 			return fmt.Errorf("failed to get properties: %w", err)
 		}
 
-		copyStatus := *props.CopyStatus
-		log.Printf("Copy status: %s", copyStatus)
+		if props.CopyStatus != nil {
+			status = *props.CopyStatus
+		}
+		if props.CopyProgress != nil && *props.CopyProgress != lastProgress {
+			lastProgress = *props.CopyProgress
+			log.Printf("Copy progress: %s bytes", lastProgress)
+		}
+	}
 
-		switch copyStatus {
-		case "success":
-			log.Println("Copy completed successfully")
-			return nil
-		case "pending":
-			time.Sleep(200 * time.Millisecond)
-		default:
-			return fmt.Errorf("copy failed or aborted with status: %s", copyStatus)
+	log.Println("Copy completed successfully")
+	return nil
+}
+
+// setContentHeaders replaces the given headers of destClient and keeps all
+// others, as the service resets every header which is not sent.
+func setContentHeaders(ctx context.Context, destClient *blockblob.Client, headers ContentHeaders) error {
+	props, err := destClient.GetProperties(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get properties of copy destination: %w", err)
+	}
+
+	httpHeaders := azBlob.HTTPHeaders{
+		BlobContentType:        props.ContentType,
+		BlobContentEncoding:    props.ContentEncoding,
+		BlobContentLanguage:    props.ContentLanguage,
+		BlobContentDisposition: props.ContentDisposition,
+		BlobCacheControl:       props.CacheControl,
+		BlobContentMD5:         props.ContentMD5,
+	}
+	for _, header := range []struct {
+		value  string
+		target **string
+	}{
+		{headers.ContentType, &httpHeaders.BlobContentType},
+		{headers.ContentEncoding, &httpHeaders.BlobContentEncoding},
+		{headers.ContentLanguage, &httpHeaders.BlobContentLanguage},
+		{headers.ContentDisposition, &httpHeaders.BlobContentDisposition},
+		{headers.CacheControl, &httpHeaders.BlobCacheControl},
+	} {
+		if header.value != "" {
+			*header.target = to.Ptr(header.value)
 		}
 	}
+
+	_, err = destClient.SetHTTPHeaders(ctx, httpHeaders, nil)
+	if err != nil {
+		return fmt.Errorf("failed to set content headers: %w", err)
+	}
+	return nil
+}
+
+func metadataOption(metadata map[string]string) map[string]*string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	result := make(map[string]*string, len(metadata))
+	for key, value := range metadata {
+		result[key] = to.Ptr(value)
+	}
+	return result
+}
+
+func tierOption(tier string) *azBlob.AccessTier {
+	if tier == "" {
+		return nil
+	}
+	return to.Ptr(azBlob.AccessTier(tier))
+}
+
+// abortCopy aborts the pending copy copyID after it was cancelled because
+// of reason, leaving an empty destination blob behind.
+func (dsc DefaultStorageClient) abortCopy(destClient *blockblob.Client, copyID string, reason error) error {
+	log.Printf("Aborting copy %s", copyID)
+	_, err := destClient.AbortCopyFromURL(context.Background(), copyID, nil)
+	if err != nil {
+		return fmt.Errorf("%w, failed to abort it: %w", dsc.copyCancelled(reason), err)
+	}
+	return fmt.Errorf("%w, aborted it", dsc.copyCancelled(reason))
+}
+
+func (dsc DefaultStorageClient) copyCancelled(reason error) error {
+	if errors.Is(reason, context.DeadlineExceeded) {
+		return fmt.Errorf("copy timeout of %s seconds reached", dsc.storageConfig.CopyTimeout)
+	}
+	return errors.New("copy interrupted")
+}
+
+// nextCopyPollInterval doubles the time between two copy status polls,
+// up to copyPollMaxInterval.
+func nextCopyPollInterval(interval time.Duration) time.Duration {
+	return min(2*interval, copyPollMaxInterval)
 }
 
 func (dsc DefaultStorageClient) Delete(
 	dest string,
+	options DeleteOptions,
 ) error {
 
 	blobURL := fmt.Sprintf("%s/%s", dsc.serviceURL, dest)
@@ -212,7 +914,26 @@ func (dsc DefaultStorageClient) Delete(
 		return err
 	}
 
-	_, err = client.Delete(context.Background(), nil)
+	deleteOptions := &azBlob.DeleteOptions{AccessConditions: leaseConditions(options.LeaseID)}
+	if options.IfMatch != "" {
+		if deleteOptions.AccessConditions == nil {
+			deleteOptions.AccessConditions = &azBlob.AccessConditions{}
+		}
+		deleteOptions.AccessConditions.ModifiedAccessConditions = &azBlob.ModifiedAccessConditions{
+			IfMatch: to.Ptr(azcore.ETag(`"` + options.IfMatch + `"`)),
+		}
+	}
+	switch options.Snapshots {
+	case "":
+	case "include":
+		deleteOptions.DeleteSnapshots = to.Ptr(azBlob.DeleteSnapshotsOptionTypeInclude)
+	case "only":
+		deleteOptions.DeleteSnapshots = to.Ptr(azBlob.DeleteSnapshotsOptionTypeOnly)
+	default:
+		return fmt.Errorf("unknown snapshots option '%s', expected 'include' or 'only'", options.Snapshots)
+	}
+
+	_, err = client.Delete(context.Background(), deleteOptions)
 
 	if err == nil {
 		return nil
@@ -225,51 +946,243 @@ func (dsc DefaultStorageClient) Delete(
 	return err
 }
 
-func (dsc DefaultStorageClient) DeleteRecursive(
-	prefix string,
-) error {
-	if prefix != "" {
-		log.Printf("Deleting all blobs in container %s with prefix '%s'\n", dsc.storageConfig.ContainerName, prefix)
-	} else {
-		log.Printf("Deleting all blobs in container %s\n", dsc.storageConfig.ContainerName)
+// MaxBatchSize is the maximum number of sub-requests of a Blob Batch request.
+const MaxBatchSize = 256
+
+// DeleteBatch deletes up to MaxBatchSize blobs with a single Blob Batch
+// request. It returns the failures of individual blobs keyed by blob name,
+// or an error if the batch as a whole could not be submitted.
+func (dsc DefaultStorageClient) DeleteBatch(
+	names []string,
+) (map[string]error, error) {
+	if len(names) > MaxBatchSize {
+		return nil, fmt.Errorf("batch of %d blobs exceeds the maximum of %d", len(names), MaxBatchSize)
 	}
 
 	containerClient, err := azContainer.NewClientWithSharedKeyCredential(dsc.serviceURL, dsc.credential, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create container client: %w", err)
+		return nil, fmt.Errorf("failed to create container client: %w", err)
 	}
 
-	options := &azContainer.ListBlobsFlatOptions{}
-	if prefix != "" {
-		options.Prefix = &prefix
+	batch, err := containerClient.NewBatchBuilder()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch: %w", err)
 	}
-
-	pager := containerClient.NewListBlobsFlatPager(options)
-
-	for pager.More() {
-		resp, err := pager.NextPage(context.Background())
+	for _, name := range names {
+		err = batch.Delete(name, nil)
 		if err != nil {
-			return fmt.Errorf("error retrieving page of blobs: %w", err)
+			return nil, fmt.Errorf("failed to add %s to batch: %w", name, err)
 		}
+	}
 
-		for _, blob := range resp.Segment.BlobItems {
-			blobURL := fmt.Sprintf("%s/%s", dsc.serviceURL, *blob.Name)
-			blobClient, err := blockblob.NewClientWithSharedKeyCredential(blobURL, dsc.credential, nil)
-			if err != nil {
-				log.Printf("Failed to create blob client for %s: %v\n", *blob.Name, err)
-				continue
-			}
+	resp, err := containerClient.SubmitBatch(context.Background(), batch, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit batch: %w", err)
+	}
 
-			_, err = blobClient.BlobClient().Delete(context.Background(), nil)
-			if err != nil && !strings.Contains(err.Error(), "RESPONSE 404") {
-				log.Printf("Failed to delete blob %s: %v\n", *blob.Name, err)
-			}
+	failures := map[string]error{}
+	for _, item := range resp.Responses {
+		if item.Error == nil || bloberror.HasCode(item.Error, bloberror.BlobNotFound) {
+			continue
 		}
+		if item.ContentID != nil && *item.ContentID < len(names) {
+			failures[names[*item.ContentID]] = item.Error
+		} else if item.BlobName != nil {
+			failures[*item.BlobName] = item.Error
+		}
+	}
+	return failures, nil
+}
+
+func (dsc DefaultStorageClient) Undelete(
+	dest string,
+) error {
+
+	blobURL := fmt.Sprintf("%s/%s", dsc.serviceURL, dest)
+
+	log.Println(fmt.Sprintf("Undeleting %s", blobURL)) //nolint:staticcheck
+	client, err := azBlob.NewClientWithSharedKeyCredential(blobURL, dsc.credential, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Undelete(context.Background(), nil)
+	return err
+}
+
+func (dsc DefaultStorageClient) CreateSnapshot(
+	dest string,
+) (string, error) {
+
+	blobURL := fmt.Sprintf("%s/%s", dsc.serviceURL, dest)
+
+	log.Println(fmt.Sprintf("Creating snapshot of %s", blobURL)) //nolint:staticcheck
+	client, err := azBlob.NewClientWithSharedKeyCredential(blobURL, dsc.credential, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.CreateSnapshot(context.Background(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create snapshot of %s: %w", dest, err)
+	}
+
+	return *resp.Snapshot, nil
+}
+
+// PromoteSnapshot replaces the content of dest with its snapshot by a
+// server-side copy.
+func (dsc DefaultStorageClient) PromoteSnapshot(
+	ctx context.Context,
+	dest string,
+	snapshot string,
+) error {
+	log.Printf("Promoting snapshot %s of blob %s", snapshot, dest)
+
+	srcURL := fmt.Sprintf("%s/%s?snapshot=%s", dsc.serviceURL, dest, url.QueryEscape(snapshot))
+	return dsc.copyFromURL(ctx, srcURL, dest)
+}
+
+// RestoreVersion makes a previous version of dest its current version by a
+// server-side copy.
+func (dsc DefaultStorageClient) RestoreVersion(
+	ctx context.Context,
+	dest string,
+	versionID string,
+) error {
+	log.Printf("Restoring version %s of blob %s", versionID, dest)
+
+	srcURL := fmt.Sprintf("%s/%s?versionid=%s", dsc.serviceURL, dest, url.QueryEscape(versionID))
+	return dsc.copyFromURL(ctx, srcURL, dest)
+}
+
+func (dsc DefaultStorageClient) leaseClient(
+	dest string,
+	leaseID string,
+) (*lease.BlobClient, error) {
+	blobURL := fmt.Sprintf("%s/%s", dsc.serviceURL, dest)
+
+	client, err := azBlob.NewClientWithSharedKeyCredential(blobURL, dsc.credential, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &lease.BlobClientOptions{}
+	if leaseID != "" {
+		options.LeaseID = &leaseID
 	}
+	return lease.NewBlobClient(client, options)
+}
+
+// AcquireLease acquires a lease on dest for duration seconds, or an infinite
+// lease for a duration of -1, and returns the lease ID.
+func (dsc DefaultStorageClient) AcquireLease(
+	dest string,
+	duration int32,
+	proposedID string,
+) (string, error) {
+	log.Printf("Acquiring lease on blob %s", dest)
 
+	client, err := dsc.leaseClient(dest, proposedID)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.AcquireLease(context.Background(), duration, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to acquire lease on %s: %w", dest, err)
+	}
+
+	return *resp.LeaseID, nil
+}
+
+func (dsc DefaultStorageClient) RenewLease(
+	ctx context.Context,
+	dest string,
+	leaseID string,
+) error {
+	log.Printf("Renewing lease on blob %s", dest)
+
+	client, err := dsc.leaseClient(dest, leaseID)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.RenewLease(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to renew lease on %s: %w", dest, err)
+	}
 	return nil
 }
 
+func (dsc DefaultStorageClient) ReleaseLease(
+	dest string,
+	leaseID string,
+) error {
+	log.Printf("Releasing lease on blob %s", dest)
+
+	client, err := dsc.leaseClient(dest, leaseID)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.ReleaseLease(context.Background(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to release lease on %s: %w", dest, err)
+	}
+	return nil
+}
+
+// BreakLease breaks the lease on dest after at most breakPeriod seconds, or
+// after the remaining lease period for a breakPeriod of -1, and returns the
+// seconds until the lease is broken.
+func (dsc DefaultStorageClient) BreakLease(
+	dest string,
+	breakPeriod int32,
+) (int32, error) {
+	log.Printf("Breaking lease on blob %s", dest)
+
+	client, err := dsc.leaseClient(dest, "")
+	if err != nil {
+		return 0, err
+	}
+
+	options := &lease.BlobBreakOptions{}
+	if breakPeriod >= 0 {
+		options.BreakPeriod = &breakPeriod
+	}
+
+	resp, err := client.BreakLease(context.Background(), options)
+	if err != nil {
+		return 0, fmt.Errorf("failed to break lease on %s: %w", dest, err)
+	}
+
+	if resp.LeaseTime == nil {
+		return 0, nil
+	}
+	return *resp.LeaseTime, nil
+}
+
+func (dsc DefaultStorageClient) ChangeLease(
+	dest string,
+	leaseID string,
+	proposedID string,
+) (string, error) {
+	log.Printf("Changing lease on blob %s", dest)
+
+	client, err := dsc.leaseClient(dest, leaseID)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.ChangeLease(context.Background(), proposedID, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to change lease on %s: %w", dest, err)
+	}
+
+	return *resp.LeaseID, nil
+}
+
 func (dsc DefaultStorageClient) Exists(
 	dest string,
 ) (bool, error) {
@@ -327,9 +1240,35 @@ func (dsc DefaultStorageClient) SignedUrl(
 	return url, err
 }
 
+// CopySourceURL returns a read-only signed URL of source, which lets the
+// service read it as the source of a copy into another container or account.
+func (dsc DefaultStorageClient) CopySourceURL(
+	source string,
+	expiration time.Duration,
+) (string, error) {
+
+	blobURL := fmt.Sprintf("%s/%s", dsc.serviceURL, source)
+
+	log.Println(fmt.Sprintf("Getting copy source url for blob %s", blobURL)) //nolint:staticcheck
+	client, err := azBlob.NewClientWithSharedKeyCredential(blobURL, dsc.credential, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return client.GetSASURL(sas.BlobPermissions{Read: true}, time.Now().Add(expiration), nil)
+}
+
+// ContainerURL returns the URL of the container, which identifies it
+// across accounts.
+func (dsc DefaultStorageClient) ContainerURL() string {
+	return dsc.serviceURL
+}
+
 func (dsc DefaultStorageClient) List(
 	prefix string,
-) ([]string, error) {
+	options ListOptions,
+	handlePage func(items []*BlobItem) error,
+) (string, error) {
 
 	if prefix != "" {
 		log.Println(fmt.Sprintf("Listing blobs in container %s with prefix '%s'", dsc.storageConfig.ContainerName, prefix)) //nolint:staticcheck
@@ -339,35 +1278,125 @@ func (dsc DefaultStorageClient) List(
 
 	client, err := azContainer.NewClientWithSharedKeyCredential(dsc.serviceURL, dsc.credential, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create container client: %w", err)
+		return "", fmt.Errorf("failed to create container client: %w", err)
 	}
 
-	options := &azContainer.ListBlobsFlatOptions{}
-	if prefix != "" {
-		options.Prefix = &prefix
-	}
+	marker := options.Marker
+	remaining := options.MaxResults
 
-	pager := client.NewListBlobsFlatPager(options)
-	var blobs []string
+	// A new pager is created for every page so that the page size can shrink
+	// to what is left of MaxResults and the listing stops exactly there.
+	for {
+		listOptions := &azContainer.ListBlobsFlatOptions{Include: options.Include}
+		if prefix != "" {
+			listOptions.Prefix = &prefix
+		}
+		if marker != "" {
+			listOptions.Marker = &marker
+		}
+		if options.MaxResults > 0 {
+			listOptions.MaxResults = &remaining
+		}
+
+		resp, err := client.NewListBlobsFlatPager(listOptions).NextPage(context.Background())
+		if err != nil {
+			return "", fmt.Errorf("error retrieving page of blobs: %w", err)
+		}
 
-	for pager.More() {
-		resp, err := pager.NextPage(context.Background())
+		err = handlePage(resp.Segment.BlobItems)
 		if err != nil {
-			return nil, fmt.Errorf("error retrieving page of blobs: %w", err)
+			return "", err
+		}
+
+		if resp.NextMarker == nil || *resp.NextMarker == "" {
+			return "", nil
 		}
+		marker = *resp.NextMarker
 
-		for _, blob := range resp.Segment.BlobItems {
-			blobs = append(blobs, *blob.Name)
+		if options.MaxResults > 0 {
+			remaining -= int32(len(resp.Segment.BlobItems))
+			if remaining <= 0 {
+				return marker, nil
+			}
 		}
 	}
+}
+
+// ListOptions controls how much of a container listing is returned.
+type ListOptions struct {
+	// MaxResults stops the listing after this many blobs. Zero lists all blobs.
+	MaxResults int32
+	// Marker resumes a listing from the continuation token of a previous one.
+	Marker string
+	// Include adds snapshots, versions, deleted blobs and further details
+	// to the listing.
+	Include ListBlobsInclude
+}
 
-	return blobs, nil
+// ListBlobsInclude selects the datasets added to a listing.
+type ListBlobsInclude = azContainer.ListBlobsInclude
+
+// ParseListInclude parses a comma separated list of the datasets which can
+// be added to a listing, e.g. "snapshots,versions,deleted".
+func ParseListInclude(value string) (azContainer.ListBlobsInclude, error) {
+	include := azContainer.ListBlobsInclude{}
+	for _, dataset := range strings.Split(value, ",") {
+		switch strings.TrimSpace(dataset) {
+		case "":
+		case "snapshots":
+			include.Snapshots = true
+		case "versions":
+			include.Versions = true
+		case "deleted":
+			include.Deleted = true
+		case "metadata":
+			include.Metadata = true
+		case "tags":
+			include.Tags = true
+		case "uncommittedblobs":
+			include.UncommittedBlobs = true
+		default:
+			return azContainer.ListBlobsInclude{}, fmt.Errorf("unknown list dataset '%s', available are snapshots, versions, deleted, metadata, tags and uncommittedblobs", dataset)
+		}
+	}
+	return include, nil
 }
 
+// BlobItem is a single entry of a container listing, including all blob
+// properties returned by the service.
+type BlobItem = azContainer.BlobItem
+
 type BlobProperties struct {
 	ETag          string    `json:"etag,omitempty"`
 	LastModified  time.Time `json:"last_modified,omitempty"`
 	ContentLength int64     `json:"content_length,omitempty"`
+	ContentMD5    []byte    `json:"content_md5,omitempty"`
+}
+
+// GetProperties returns the properties of dest. A missing blob is reported
+// as a bloberror.BlobNotFound error.
+func (dsc DefaultStorageClient) GetProperties(
+	dest string,
+) (BlobProperties, error) {
+	blobURL := fmt.Sprintf("%s/%s", dsc.serviceURL, dest)
+
+	log.Println(fmt.Sprintf("Getting properties for blob %s", blobURL)) //nolint:staticcheck
+	client, err := blockblob.NewClientWithSharedKeyCredential(blobURL, dsc.credential, nil)
+	if err != nil {
+		return BlobProperties{}, err
+	}
+
+	resp, err := client.GetProperties(context.Background(), nil)
+	if err != nil {
+		return BlobProperties{}, fmt.Errorf("failed to get properties for blob %s: %w", dest, err)
+	}
+
+	return BlobProperties{
+		ETag:          strings.Trim(string(*resp.ETag), `"`),
+		LastModified:  *resp.LastModified,
+		ContentLength: *resp.ContentLength,
+		ContentMD5:    resp.ContentMD5,
+	}, nil
 }
 
 func (dsc DefaultStorageClient) Properties(
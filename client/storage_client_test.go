@@ -0,0 +1,255 @@
+package client_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DefaultStorageClient", func() {
+	var (
+		service     *blobService
+		azBlobstore client.AzBlobstore
+	)
+
+	BeforeEach(func() {
+		service = newBlobService("account", "stemcells")
+		DeferCleanup(service.Close)
+		azBlobstore = service.Blobstore()
+	})
+
+	Context("Copy", func() {
+		It("copies small blobs synchronously and verifies them against the source MD5", func() {
+			service.Put("source", "content")
+			service.SetProperties("source", http.Header{"X-Ms-Meta-Owner": {"bosh"}})
+
+			err := azBlobstore.Copy(context.Background(), "source", "copy", client.CopyOptions{VerifyMD5: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(service.Contents()).To(HaveKeyWithValue("copy", "content"))
+			Expect(service.Properties("copy").Get("X-Ms-Meta-Owner")).To(Equal("bosh"))
+		})
+
+		It("fails the synchronous copy when the source does not match its Content-MD5", func() {
+			service.Put("source", "content")
+			service.SetProperties("source", http.Header{"Content-Md5": {contentMD5("other")}})
+
+			err := azBlobstore.Copy(context.Background(), "source", "copy", client.CopyOptions{VerifyMD5: true})
+			Expect(err).To(MatchError(ContainSubstring("Md5Mismatch")))
+			Expect(service.Contents()).ToNot(HaveKey("copy"))
+		})
+
+		It("replaces the metadata of the source when given", func() {
+			service.Put("source", "content")
+			service.SetProperties("source", http.Header{"X-Ms-Meta-Owner": {"bosh"}})
+
+			err := azBlobstore.Copy(context.Background(), "source", "copy", client.CopyOptions{Metadata: map[string]string{"stage": "release"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(service.Properties("copy").Get("X-Ms-Meta-Stage")).To(Equal("release"))
+			Expect(service.Properties("copy")).ToNot(HaveKey("X-Ms-Meta-Owner"))
+		})
+
+		It("replaces the given content headers and keeps the others", func() {
+			service.Put("source", "content")
+			service.SetProperties("source", http.Header{
+				"Content-Type":     {"application/octet-stream"},
+				"Content-Language": {"en"},
+			})
+
+			err := azBlobstore.Copy(context.Background(), "source", "copy", client.CopyOptions{
+				Headers: client.ContentHeaders{ContentType: "text/plain", CacheControl: "no-cache"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			properties := service.Properties("copy")
+			Expect(properties.Get("Content-Type")).To(Equal("text/plain"))
+			Expect(properties.Get("Cache-Control")).To(Equal("no-cache"))
+			Expect(properties.Get("Content-Language")).To(Equal("en"))
+
+			err = azBlobstore.Copy(context.Background(), "copy", "verified", client.CopyOptions{VerifyMD5: true})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("hashes the content of large copies to verify them", func() {
+			service.PutLarge("source", "content", 512*1024*1024)
+
+			err := azBlobstore.Copy(context.Background(), "source", "copy", client.CopyOptions{VerifyMD5: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(service.Contents()).To(HaveKeyWithValue("copy", "content"))
+		})
+
+		It("fails large copies whose content does not match the source MD5", func() {
+			service.PutLarge("source", "content", 512*1024*1024)
+			service.CorruptCopies()
+
+			err := azBlobstore.Copy(context.Background(), "source", "copy", client.CopyOptions{VerifyMD5: true})
+			Expect(err).To(MatchError(ContainSubstring("does not match the source MD5")))
+		})
+
+		It("does not verify large copies unless asked to", func() {
+			service.PutLarge("source", "content", 512*1024*1024)
+			service.CorruptCopies()
+
+			err := azBlobstore.Copy(context.Background(), "source", "copy", client.CopyOptions{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(service.Contents()).To(HaveKeyWithValue("copy", "content!"))
+		})
+
+		It("aborts a pending copy once the copy timeout is reached", func() {
+			service.PutLarge("source", "content", 512*1024*1024)
+			service.PendCopies()
+			storageConfig := service.Config()
+			storageConfig.CopyTimeout = "1"
+			azBlobstore = service.BlobstoreWith(storageConfig)
+
+			err := azBlobstore.Copy(context.Background(), "source", "copy", client.CopyOptions{})
+			Expect(err).To(MatchError("copy timeout of 1 seconds reached, aborted it"))
+			Expect(service.AbortedCopies()).To(HaveLen(1))
+
+			// Polled after 200ms and 400ms more, the next poll would come
+			// after the timeout.
+			polls := service.CopyPolls()
+			Expect(polls).To(HaveLen(2))
+			Expect(polls[1].Sub(polls[0])).To(BeNumerically("~", 400*time.Millisecond, 150*time.Millisecond))
+		})
+
+		It("aborts a pending copy when the context is cancelled", func() {
+			service.PutLarge("source", "content", 512*1024*1024)
+			service.PendCopies()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			errs := make(chan error, 1)
+			go func() {
+				errs <- azBlobstore.Copy(ctx, "source", "copy", client.CopyOptions{})
+			}()
+			Eventually(service.CopyPolls).ShouldNot(BeEmpty())
+			cancel()
+
+			Eventually(errs).Should(Receive(MatchError("copy interrupted, aborted it")))
+			Expect(service.AbortedCopies()).To(HaveLen(1))
+		})
+	})
+
+	Context("Upload and Download with CRC64 and SHA-256", func() {
+		// Two blocks of 8 MiB when uploaded and three ranges of 4 MiB when
+		// downloaded.
+		content := strings.Repeat("0123456789abcdef", 9*1024*1024/16)
+
+		put := func(options client.UploadOptions) error {
+			path := filepath.Join(GinkgoT().TempDir(), "source")
+			Expect(os.WriteFile(path, []byte(content), 0600)).To(Succeed())
+			return azBlobstore.Put(path, "stemcell", options)
+		}
+
+		get := func(options client.DownloadOptions) (string, error) {
+			dest, err := os.Create(filepath.Join(GinkgoT().TempDir(), "dest"))
+			Expect(err).ToNot(HaveOccurred())
+			defer dest.Close() //nolint:errcheck
+			err = azBlobstore.Get("stemcell", dest, options)
+			if err != nil {
+				return "", err
+			}
+			downloaded, err := os.ReadFile(dest.Name())
+			Expect(err).ToNot(HaveOccurred())
+			return string(downloaded), nil
+		}
+
+		sha256Hex := func(content string) string {
+			sum := sha256.Sum256([]byte(content))
+			return hex.EncodeToString(sum[:])
+		}
+
+		It("uploads blocks with IDs of the same length and commits the Content-MD5", func() {
+			Expect(put(client.UploadOptions{CRC64: true})).To(Succeed())
+
+			Expect(service.Contents()).To(HaveKeyWithValue("stemcell", content))
+			Expect(service.BlockIDs("stemcell")).To(Equal([]string{
+				base64.StdEncoding.EncodeToString([]byte("00000000")),
+				base64.StdEncoding.EncodeToString([]byte("00000001")),
+			}))
+			Expect(service.MD5("stemcell")).To(Equal(contentMD5(content)))
+			Expect(service.Properties("stemcell")).ToNot(HaveKey("X-Ms-Meta-Sha256"))
+		})
+
+		It("commits the sha256 metadata instead of the Content-MD5", func() {
+			Expect(put(client.UploadOptions{SHA256: true})).To(Succeed())
+
+			Expect(service.Contents()).To(HaveKeyWithValue("stemcell", content))
+			Expect(service.MD5("stemcell")).To(BeEmpty())
+			Expect(service.Properties("stemcell").Get("X-Ms-Meta-Sha256")).To(Equal(sha256Hex(content)))
+		})
+
+		It("fails the upload when a block does not match its CRC64", func() {
+			service.CorruptUploads()
+
+			err := put(client.UploadOptions{CRC64: true})
+			Expect(err).To(MatchError(ContainSubstring("failed to upload block 0")))
+			Expect(err).To(MatchError(ContainSubstring("Crc64Mismatch")))
+			Expect(service.Contents()).ToNot(HaveKey("stemcell"))
+		})
+
+		It("downloads ranges validated by their CRC64 and verifies the Content-MD5", func() {
+			service.Put("stemcell", content)
+
+			Expect(get(client.DownloadOptions{CRC64: true})).To(Equal(content))
+		})
+
+		It("rejects a range which does not match its CRC64", func() {
+			service.Put("stemcell", content)
+			service.CorruptDownloads()
+
+			_, err := get(client.DownloadOptions{CRC64: true})
+			Expect(err).To(MatchError(HavePrefix("the range at 0 does not match its CRC64")))
+		})
+
+		It("fails the download when the content does not match the Content-MD5", func() {
+			service.Put("stemcell", content)
+			service.SetProperties("stemcell", http.Header{"Content-Md5": {contentMD5("other")}})
+
+			_, err := get(client.DownloadOptions{CRC64: true})
+			Expect(err).To(MatchError(ContainSubstring("does not match the blob MD5")))
+		})
+
+		It("verifies the download against the sha256 metadata", func() {
+			service.Put("stemcell", content)
+			service.SetProperties("stemcell", http.Header{"X-Ms-Meta-Sha256": {sha256Hex(content)}})
+
+			Expect(get(client.DownloadOptions{SHA256: true, CRC64: true})).To(Equal(content))
+		})
+
+		It("fails the download when the content does not match the sha256 metadata", func() {
+			service.Put("stemcell", content)
+			service.SetProperties("stemcell", http.Header{"X-Ms-Meta-Sha256": {sha256Hex("other")}})
+
+			_, err := get(client.DownloadOptions{SHA256: true})
+			Expect(err).To(MatchError(ContainSubstring("does not match the blob SHA-256")))
+		})
+	})
+
+	It("doubles the copy poll interval up to the maximum", func() {
+		var intervals []time.Duration
+		for interval := client.CopyPollMinInterval; len(intervals) < 8; interval = client.NextCopyPollInterval(interval) {
+			intervals = append(intervals, interval)
+		}
+		Expect(intervals).To(Equal([]time.Duration{
+			200 * time.Millisecond,
+			400 * time.Millisecond,
+			800 * time.Millisecond,
+			1600 * time.Millisecond,
+			3200 * time.Millisecond,
+			6400 * time.Millisecond,
+			client.CopyPollMaxInterval,
+			client.CopyPollMaxInterval,
+		}))
+	})
+})
@@ -0,0 +1,312 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type SyncOptions struct {
+	// Concurrency is the number of files transferred at the same time.
+	Concurrency int
+	// Delete removes files or blobs from the destination which do not exist
+	// at the source.
+	Delete bool
+	// Exclude skips files and blobs whose path relative to the directory or
+	// prefix matches one of these patterns, using the rules of
+	// ListFilter.Exclude. Excluded destinations are never deleted.
+	Exclude []string
+	// All confirms that Delete may remove blobs or files for the whole
+	// container when no prefix is given.
+	All bool
+}
+
+// checkDelete refuses to delete extra blobs or files against the whole
+// container unless All is set.
+func (options SyncOptions) checkDelete(prefix string) error {
+	if options.Delete && prefix == "" && !options.All {
+		return errors.New("refusing to sync the whole container with --delete without a prefix, pass --all to confirm")
+	}
+	return nil
+}
+
+// SyncUp uploads every file below dir to the same path below prefix,
+// skipping blobs which already have the size and MD5 of their file.
+// Files which could not be uploaded are returned as BlobFailures.
+func (client *AzBlobstore) SyncUp(dir string, prefix string, options SyncOptions) (TransferSummary, error) {
+	prefix = directoryPrefix(prefix)
+
+	if err := options.checkDelete(prefix); err != nil {
+		return TransferSummary{}, err
+	}
+
+	files, remote, err := client.syncUpSides(dir, prefix, options.Exclude)
+	if err != nil {
+		return TransferSummary{}, err
+	}
+
+	tally := newTransferTally("sync", "Uploaded")
+	forEachParallel(files, options.Concurrency, func(file localFile) {
+		if props, ok := remote[file.Name]; ok {
+			upToDate, err := client.localUpToDate(file, props)
+			if err != nil || upToDate {
+				tally.add(file.Name, file.Size, upToDate, err)
+				return
+			}
+		}
+		err := client.Put(file.Path, prefix+file.Name, UploadOptions{})
+		tally.add(file.Name, file.Size, false, err)
+	})
+
+	if options.Delete {
+		forEachParallel(remoteExtras(files, remote), options.Concurrency, func(name string) {
+			tally.delete(name, client.storageClient.Delete(prefix+name, DeleteOptions{}))
+		})
+	}
+
+	return tally.finish(nil)
+}
+
+// DryRunSyncUp writes what SyncUp would upload and delete to out without
+// changing anything.
+func (client *AzBlobstore) DryRunSyncUp(dir string, prefix string, options SyncOptions, out io.Writer) (DryRunSummary, error) {
+	prefix = directoryPrefix(prefix)
+
+	if err := options.checkDelete(prefix); err != nil {
+		return DryRunSummary{}, err
+	}
+
+	files, remote, err := client.syncUpSides(dir, prefix, options.Exclude)
+	if err != nil {
+		return DryRunSummary{}, err
+	}
+
+	uploads := newDryRun("upload", out)
+	uploads.unit = "files"
+	for _, file := range files {
+		if props, ok := remote[file.Name]; ok {
+			upToDate, err := client.localUpToDate(file, props)
+			if err != nil {
+				return DryRunSummary{}, err
+			}
+			if upToDate {
+				continue
+			}
+		}
+		if err := uploads.addName(file.Path, prefix+file.Name, file.Size); err != nil {
+			return DryRunSummary{}, err
+		}
+	}
+	summary, err := uploads.finish()
+	if err != nil || !options.Delete {
+		return summary, err
+	}
+
+	deletes := newDryRun("delete", out)
+	for _, name := range remoteExtras(files, remote) {
+		if err := deletes.addName(prefix+name, "", remote[name].ContentLength); err != nil {
+			return DryRunSummary{}, err
+		}
+	}
+	deleted, err := deletes.finish()
+	return summary.plus(deleted), err
+}
+
+// syncUpSides returns the files below dir and the blobs below prefix keyed
+// by their relative name, skipping excluded paths.
+func (client *AzBlobstore) syncUpSides(dir string, prefix string, exclude []string) ([]localFile, map[string]BlobProperties, error) {
+	files, err := walkLocal(dir, exclude)
+	if err != nil {
+		return nil, nil, err
+	}
+	remote, err := client.listRelative(prefix, exclude)
+	if err != nil {
+		return nil, nil, err
+	}
+	return files, remote, nil
+}
+
+// remoteExtras returns the names of the blobs in remote which have no file
+// in files, in order.
+func remoteExtras(files []localFile, remote map[string]BlobProperties) []string {
+	local := make(map[string]bool, len(files))
+	for _, file := range files {
+		local[file.Name] = true
+	}
+	var extras []string
+	for _, name := range sortedNames(remote) {
+		if !local[name] {
+			extras = append(extras, name)
+		}
+	}
+	return extras
+}
+
+// SyncDown downloads every blob below prefix to the same path below dir,
+// skipping files which already have the size and MD5 of their blob. Blobs
+// whose name would end up outside of dir are rejected, and files are only
+// written and deleted through dir, never through symlinks pointing out of
+// it. Blobs which could not be downloaded are returned as BlobFailures.
+func (client *AzBlobstore) SyncDown(prefix string, dir string, options SyncOptions) (TransferSummary, error) {
+	prefix = directoryPrefix(prefix)
+
+	if err := options.checkDelete(prefix); err != nil {
+		return TransferSummary{}, err
+	}
+
+	remote, files, err := client.syncDownSides(prefix, dir, options.Exclude)
+	if err != nil {
+		return TransferSummary{}, err
+	}
+	local := make(map[string]localFile, len(files))
+	for _, file := range files {
+		local[file.Name] = file
+	}
+
+	root, err := openDownloadRoot(dir)
+	if err != nil {
+		return TransferSummary{}, err
+	}
+	defer root.Close() //nolint:errcheck
+
+	tally := newTransferTally("sync", "Downloaded")
+	forEachParallel(sortedNames(remote), options.Concurrency, func(name string) {
+		props := remote[name]
+		target, err := localName(name)
+		if err != nil {
+			tally.add(name, props.ContentLength, false, err)
+			return
+		}
+		if file, ok := local[name]; ok {
+			upToDate, err := client.localUpToDate(file, props)
+			if err != nil || upToDate {
+				tally.add(name, props.ContentLength, upToDate, err)
+				return
+			}
+		}
+		err = client.download(root, prefix+name, target, props.ContentMD5)
+		tally.add(name, props.ContentLength, false, err)
+	})
+
+	if options.Delete {
+		forEachParallel(localExtras(files, remote), options.Concurrency, func(file localFile) {
+			log.Printf("Deleting %s", file.Path)
+			tally.delete(file.Name, root.Remove(filepath.FromSlash(file.Name)))
+		})
+	}
+
+	return tally.finish(nil)
+}
+
+// DryRunSyncDown writes what SyncDown would download and delete to out
+// without changing anything.
+func (client *AzBlobstore) DryRunSyncDown(prefix string, dir string, options SyncOptions, out io.Writer) (DryRunSummary, error) {
+	prefix = directoryPrefix(prefix)
+
+	if err := options.checkDelete(prefix); err != nil {
+		return DryRunSummary{}, err
+	}
+
+	remote, files, err := client.syncDownSides(prefix, dir, options.Exclude)
+	if err != nil {
+		return DryRunSummary{}, err
+	}
+	local := make(map[string]localFile, len(files))
+	for _, file := range files {
+		local[file.Name] = file
+	}
+
+	downloads := newDryRun("download", out)
+	for _, name := range sortedNames(remote) {
+		props := remote[name]
+		target, err := localPath(dir, name)
+		if err != nil {
+			return DryRunSummary{}, err
+		}
+		if file, ok := local[name]; ok {
+			upToDate, err := client.localUpToDate(file, props)
+			if err != nil {
+				return DryRunSummary{}, err
+			}
+			if upToDate {
+				continue
+			}
+		}
+		if err := downloads.addName(prefix+name, target, props.ContentLength); err != nil {
+			return DryRunSummary{}, err
+		}
+	}
+	summary, err := downloads.finish()
+	if err != nil || !options.Delete {
+		return summary, err
+	}
+
+	deletes := newDryRun("delete", out)
+	deletes.unit = "files"
+	for _, file := range localExtras(files, remote) {
+		if err := deletes.addName(file.Path, "", file.Size); err != nil {
+			return DryRunSummary{}, err
+		}
+	}
+	deleted, err := deletes.finish()
+	return summary.plus(deleted), err
+}
+
+// syncDownSides returns the blobs below prefix and the files below dir keyed
+// by their relative name, skipping excluded paths. A missing dir has no
+// files.
+func (client *AzBlobstore) syncDownSides(prefix string, dir string, exclude []string) (map[string]BlobProperties, []localFile, error) {
+	remote, err := client.listRelative(prefix, exclude)
+	if err != nil {
+		return nil, nil, err
+	}
+	var files []localFile
+	if _, err := os.Stat(dir); !errors.Is(err, fs.ErrNotExist) {
+		files, err = walkLocal(dir, exclude)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return remote, files, nil
+}
+
+// localExtras returns the files which have no blob in remote.
+func localExtras(files []localFile, remote map[string]BlobProperties) []localFile {
+	var extras []localFile
+	for _, file := range files {
+		if _, ok := remote[file.Name]; !ok {
+			extras = append(extras, file)
+		}
+	}
+	return extras
+}
+
+// listRelative returns the properties of all blobs below prefix keyed by
+// their name relative to prefix, skipping excluded names.
+func (client *AzBlobstore) listRelative(prefix string, exclude []string) (map[string]BlobProperties, error) {
+	blobs := map[string]BlobProperties{}
+	_, err := client.List(prefix, ListFilter{}, ListOptions{}, func(items []*BlobItem) error {
+		for _, item := range items {
+			name := strings.TrimPrefix(blobName(item), prefix)
+			if name == "" || strings.HasSuffix(name, "/") || matchesAny(exclude, name) {
+				continue
+			}
+			blobs[name] = itemProperties(item)
+		}
+		return nil
+	})
+	return blobs, err
+}
+
+// directoryPrefix makes prefix name a virtual directory, so that syncing
+// "releases" does not pick up "releases-old/".
+func directoryPrefix(prefix string) string {
+	if prefix == "" || strings.HasSuffix(prefix, "/") {
+		return prefix
+	}
+	return prefix + "/"
+}
@@ -0,0 +1,245 @@
+package client_test
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	azContainer "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client"
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client/clientfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Sync", func() {
+	var (
+		storageClient *clientfakes.FakeStorageClient
+		azBlobstore   client.AzBlobstore
+		dir           string
+		blobs         map[string]string
+		mutex         sync.Mutex
+	)
+
+	md5Of := func(content string) []byte {
+		sum := md5.Sum([]byte(content))
+		return sum[:]
+	}
+
+	writeFile := func(name string, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		Expect(os.MkdirAll(filepath.Dir(path), 0o755)).To(Succeed())
+		Expect(os.WriteFile(path, []byte(content), 0o644)).To(Succeed())
+	}
+
+	readFile := func(name string) string {
+		content, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		Expect(err).ToNot(HaveOccurred())
+		return string(content)
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+		blobs = map[string]string{}
+
+		storageClient = &clientfakes.FakeStorageClient{}
+		storageClient.ListStub = func(prefix string, _ client.ListOptions, handlePage func([]*client.BlobItem) error) (string, error) {
+			mutex.Lock()
+			var items []*client.BlobItem
+			for name, content := range blobs {
+				size := int64(len(content))
+				items = append(items, &client.BlobItem{
+					Name:       &name,
+					Properties: &azContainer.BlobProperties{ContentLength: &size, ContentMD5: md5Of(content)},
+				})
+			}
+			mutex.Unlock()
+			return "", handlePage(items)
+		}
+		storageClient.UploadStub = func(source io.ReadSeekCloser, name string, _ client.UploadOptions) ([]byte, error) {
+			content, err := io.ReadAll(source)
+			if err != nil {
+				return nil, err
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			blobs[name] = string(content)
+			return md5Of(string(content)), nil
+		}
+		storageClient.DownloadStreamStub = func(name string) (io.ReadCloser, error) {
+			mutex.Lock()
+			content := blobs[name]
+			mutex.Unlock()
+			return io.NopCloser(strings.NewReader(content)), nil
+		}
+		storageClient.DeleteStub = func(name string, _ client.DeleteOptions) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			delete(blobs, name)
+			return nil
+		}
+
+		azBlobstore, _ = client.New(storageClient) //nolint:errcheck
+	})
+
+	It("refuses to delete against the whole container without All", func() {
+		writeFile("a", "a")
+		blobs["other"] = "other"
+		options := client.SyncOptions{Delete: true}
+
+		_, err := azBlobstore.SyncUp(dir, "", options)
+		Expect(err).To(MatchError(ContainSubstring("pass --all to confirm")))
+		_, err = azBlobstore.DryRunSyncUp(dir, "", options, &bytes.Buffer{})
+		Expect(err).To(MatchError(ContainSubstring("pass --all to confirm")))
+		_, err = azBlobstore.SyncDown("", dir, options)
+		Expect(err).To(MatchError(ContainSubstring("pass --all to confirm")))
+		_, err = azBlobstore.DryRunSyncDown("", dir, options, &bytes.Buffer{})
+		Expect(err).To(MatchError(ContainSubstring("pass --all to confirm")))
+		Expect(blobs).To(HaveKey("other"))
+		Expect(storageClient.UploadCallCount()).To(Equal(0))
+		Expect(filepath.Join(dir, "a")).To(BeAnExistingFile())
+
+		options.All = true
+		summary, err := azBlobstore.SyncUp(dir, "", options)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(summary.Deleted).To(Equal(int64(1)))
+		Expect(blobs).To(Equal(map[string]string{"a": "a"}))
+	})
+
+	Context("up", func() {
+		It("uploads new and changed files and skips unchanged ones", func() {
+			writeFile("a", "same")
+			writeFile("sub/b", "changed")
+			writeFile("sub/c", "new")
+			blobs["releases/a"] = "same"
+			blobs["releases/sub/b"] = "old"
+
+			summary, err := azBlobstore.SyncUp(dir, "releases", client.SyncOptions{Concurrency: 2})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summary).To(Equal(client.TransferSummary{Transferred: 2, Skipped: 1, Bytes: 10}))
+
+			Expect(blobs).To(Equal(map[string]string{
+				"releases/a":     "same",
+				"releases/sub/b": "changed",
+				"releases/sub/c": "new",
+			}))
+		})
+
+		It("deletes extraneous blobs but keeps excluded ones", func() {
+			writeFile("a", "a")
+			blobs["releases/a"] = "a"
+			blobs["releases/gone"] = "gone"
+			blobs["releases/keep.tmp"] = "keep"
+
+			summary, err := azBlobstore.SyncUp(dir, "releases/", client.SyncOptions{Delete: true, Exclude: []string{"*.tmp"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summary).To(Equal(client.TransferSummary{Skipped: 1, Deleted: 1}))
+			Expect(blobs).To(HaveKey("releases/keep.tmp"))
+			Expect(blobs).ToNot(HaveKey("releases/gone"))
+		})
+
+		It("prints what would be uploaded and deleted without changing anything", func() {
+			writeFile("a", "same")
+			writeFile("b", "new")
+			blobs["releases/a"] = "same"
+			blobs["releases/gone"] = "gone"
+
+			out := &bytes.Buffer{}
+			summary, err := azBlobstore.DryRunSyncUp(dir, "releases", client.SyncOptions{Delete: true}, out)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summary).To(Equal(client.DryRunSummary{Count: 2, Bytes: 7}))
+			Expect(out.String()).To(Equal(
+				"would upload " + filepath.Join(dir, "b") + " to releases/b (3 bytes)\n" +
+					"would upload 1 files (3 bytes)\n" +
+					"would delete releases/gone (4 bytes)\n" +
+					"would delete 1 blobs (4 bytes)\n"))
+			Expect(storageClient.UploadCallCount()).To(Equal(0))
+			Expect(storageClient.DeleteCallCount()).To(Equal(0))
+		})
+
+		It("reports the files which could not be uploaded", func() {
+			writeFile("a", "a")
+			storageClient.UploadReturns(nil, errors.New("boom"))
+			storageClient.UploadStub = nil
+
+			summary, err := azBlobstore.SyncUp(dir, "releases", client.SyncOptions{})
+			Expect(summary.Failed).To(Equal(int64(1)))
+			Expect(err).To(MatchError(ContainSubstring("failed to sync 1 blobs:\n  a: upload failure: boom")))
+		})
+	})
+
+	Context("down", func() {
+		It("downloads new and changed blobs and skips unchanged ones", func() {
+			writeFile("a", "same")
+			writeFile("sub/b", "old")
+			blobs["releases/a"] = "same"
+			blobs["releases/sub/b"] = "changed"
+			blobs["releases/sub/c"] = "new"
+
+			summary, err := azBlobstore.SyncDown("releases", dir, client.SyncOptions{Concurrency: 2})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summary).To(Equal(client.TransferSummary{Transferred: 2, Skipped: 1, Bytes: 10}))
+
+			Expect(readFile("sub/b")).To(Equal("changed"))
+			Expect(readFile("sub/c")).To(Equal("new"))
+			Expect(storageClient.DownloadStreamCallCount()).To(Equal(2))
+		})
+
+		It("deletes extraneous files", func() {
+			writeFile("gone", "gone")
+			blobs["releases/a"] = "a"
+
+			summary, err := azBlobstore.SyncDown("releases", dir, client.SyncOptions{Delete: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summary.Deleted).To(Equal(int64(1)))
+			Expect(filepath.Join(dir, "gone")).ToNot(BeAnExistingFile())
+		})
+
+		It("prints what would be downloaded and deleted without changing anything", func() {
+			writeFile("a", "same")
+			writeFile("gone", "gone")
+			blobs["releases/a"] = "same"
+			blobs["releases/b"] = "new"
+
+			out := &bytes.Buffer{}
+			summary, err := azBlobstore.DryRunSyncDown("releases", dir, client.SyncOptions{Delete: true}, out)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(summary).To(Equal(client.DryRunSummary{Count: 2, Bytes: 7}))
+			Expect(out.String()).To(Equal(
+				"would download releases/b to " + filepath.Join(dir, "b") + " (3 bytes)\n" +
+					"would download 1 blobs (3 bytes)\n" +
+					"would delete " + filepath.Join(dir, "gone") + " (4 bytes)\n" +
+					"would delete 1 files (4 bytes)\n"))
+			Expect(storageClient.DownloadStreamCallCount()).To(Equal(0))
+			Expect(filepath.Join(dir, "gone")).To(BeAnExistingFile())
+		})
+
+		It("rejects blob names outside of the directory", func() {
+			blobs["releases/../escape"] = "evil"
+
+			summary, err := azBlobstore.SyncDown("releases", dir, client.SyncOptions{})
+			Expect(summary.Failed).To(Equal(int64(1)))
+			Expect(err).To(MatchError(ContainSubstring("refusing to write blob name '../escape' to a local path")))
+			Expect(filepath.Join(filepath.Dir(dir), "escape")).ToNot(BeAnExistingFile())
+		})
+
+		It("keeps the existing file if the download does not match the blob MD5", func() {
+			writeFile("a", "old")
+			blobs["releases/a"] = "new"
+			storageClient.DownloadStreamStub = func(string) (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader("corrupt")), nil
+			}
+
+			_, err := azBlobstore.SyncDown("releases", dir, client.SyncOptions{})
+			Expect(err).To(MatchError(ContainSubstring("does not match the blob MD5")))
+			Expect(readFile("a")).To(Equal("old"))
+		})
+	})
+})
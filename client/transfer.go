@@ -0,0 +1,242 @@
+package client
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TransferSummary counts the outcome of a bulk copy, upload or download.
+type TransferSummary struct {
+	Transferred int64 `json:"transferred"`
+	Skipped     int64 `json:"skipped"`
+	Deleted     int64 `json:"deleted,omitempty"`
+	Failed      int64 `json:"failed"`
+	// Bytes is the size of all transferred blobs.
+	Bytes int64 `json:"bytes"`
+}
+
+func (s TransferSummary) String() string {
+	deleted := ""
+	if s.Deleted > 0 {
+		deleted = fmt.Sprintf(", %d deleted", s.Deleted)
+	}
+	return fmt.Sprintf("%d transferred (%d bytes), %d skipped%s, %d failed", s.Transferred, s.Bytes, s.Skipped, deleted, s.Failed)
+}
+
+// localFile is a regular file found below a local directory.
+type localFile struct {
+	// Name is the slash separated path relative to the directory.
+	Name string
+	Path string
+	Size int64
+}
+
+// walkLocal returns all regular files below dir. Files whose relative name
+// matches one of the exclude patterns are skipped, using the rules of
+// ListFilter.Exclude.
+func walkLocal(dir string, exclude []string) ([]localFile, error) {
+	var files []localFile
+	err := filepath.WalkDir(dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, filePath)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if matchesAny(exclude, name) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, localFile{Name: name, Path: filePath, Size: info.Size()})
+		return nil
+	})
+	return files, err
+}
+
+// localPath returns the path below dir for the slash separated relative
+// name of a blob. Names which would end up outside of dir are rejected.
+func localPath(dir string, name string) (string, error) {
+	local, err := localName(name)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, local), nil
+}
+
+// localName returns the slash separated relative name of a blob as a local
+// relative path. Names which would leave the directory are rejected.
+func localName(name string) (string, error) {
+	if name == "" || path.IsAbs(name) || strings.Contains(name, `\`) {
+		return "", fmt.Errorf("refusing to write blob name '%s' to a local path", name)
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			return "", fmt.Errorf("refusing to write blob name '%s' to a local path", name)
+		}
+	}
+	local := filepath.FromSlash(name)
+	if !filepath.IsLocal(local) {
+		return "", fmt.Errorf("refusing to write blob name '%s' to a local path", name)
+	}
+	return local, nil
+}
+
+// openDownloadRoot creates dir if needed and opens it as the root which
+// downloads are written below.
+func openDownloadRoot(dir string) (*os.Root, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return os.OpenRoot(dir)
+}
+
+// download writes the blob source to the relative path name below root,
+// creating its directory. All files are created through root, so symlinks
+// below it cannot redirect the download outside of it. The blob is hashed
+// while it is written to a temporary file next to name, which is only
+// renamed to name once its MD5 matches expectedMD5, if one is given.
+func (client *AzBlobstore) download(root *os.Root, source string, name string, expectedMD5 []byte) error {
+	err := root.MkdirAll(filepath.Dir(name), 0o755)
+	if err != nil {
+		return err
+	}
+
+	tempFile, tempName, err := createTemp(root, filepath.Join(filepath.Dir(name), "."+filepath.Base(name)+"."))
+	if err != nil {
+		return err
+	}
+	defer root.Remove(tempName) //nolint:errcheck
+
+	hash := md5.New()
+	err = client.downloadTo(source, io.MultiWriter(tempFile, hash))
+	if closeErr := tempFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(expectedMD5) > 0 {
+		if sum := hash.Sum(nil); !bytes.Equal(sum, expectedMD5) {
+			return fmt.Errorf("the downloaded MD5 %v does not match the blob MD5 %v", sum, expectedMD5)
+		}
+	}
+
+	return root.Rename(tempName, name)
+}
+
+// createTemp creates a new file below root whose name starts with prefix,
+// like os.CreateTemp, and returns it with its name.
+func createTemp(root *os.Root, prefix string) (*os.File, string, error) {
+	for range 10000 {
+		name := prefix + strconv.FormatUint(uint64(rand.Uint32()), 10)
+		file, err := root.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		return file, name, err
+	}
+	return nil, "", fmt.Errorf("failed to create a temporary file %s* in %s", prefix, root.Name())
+}
+
+// downloadTo copies the content of the blob source to dest.
+func (client *AzBlobstore) downloadTo(source string, dest io.Writer) error {
+	body, err := client.storageClient.DownloadStream(source)
+	if err != nil {
+		return err
+	}
+	defer body.Close() //nolint:errcheck
+
+	_, err = io.Copy(dest, body)
+	return err
+}
+
+// localUpToDate reports whether the local file has the length and MD5 of a
+// blob with props. Blobs without a Content-MD5 are never up to date.
+func (client *AzBlobstore) localUpToDate(file localFile, props BlobProperties) (bool, error) {
+	if file.Size != props.ContentLength || len(props.ContentMD5) == 0 {
+		return false, nil
+	}
+	md5, err := client.getMD5(file.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(md5, props.ContentMD5), nil
+}
+
+// transferTally collects the summary and failures of parallel transfers.
+type transferTally struct {
+	mutex    sync.Mutex
+	summary  TransferSummary
+	failures *BlobFailures
+	progress *progress
+}
+
+func newTransferTally(operation string, action string) *transferTally {
+	return &transferTally{
+		failures: &BlobFailures{Operation: operation, Errors: map[string]error{}},
+		progress: startProgress(action, "files"),
+	}
+}
+
+// add records the outcome of transferring name of the given size.
+func (t *transferTally) add(name string, size int64, skipped bool, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	switch {
+	case err != nil:
+		t.failures.Errors[name] = err
+		t.summary.Failed++
+	case skipped:
+		t.summary.Skipped++
+	default:
+		t.summary.Transferred++
+		t.summary.Bytes += size
+		t.progress.add(1)
+	}
+}
+
+// delete records the outcome of deleting the extraneous name.
+func (t *transferTally) delete(name string, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if err != nil {
+		t.failures.Errors[name] = err
+		t.summary.Failed++
+		return
+	}
+	t.summary.Deleted++
+}
+
+// finish stops the progress logging and returns the summary together with
+// err joined with all failures.
+func (t *transferTally) finish(err error) (TransferSummary, error) {
+	t.progress.stop()
+	return t.summary, t.failures.join(err)
+}
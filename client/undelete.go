@@ -0,0 +1,114 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sync"
+)
+
+// undeleteConcurrency is the number of blobs restored in parallel.
+const undeleteConcurrency = 16
+
+var listDeleted = ListOptions{Include: ListBlobsInclude{Deleted: true}}
+
+// Undelete restores the soft-deleted blob dest together with its
+// soft-deleted snapshots.
+func (client *AzBlobstore) Undelete(dest string) error {
+	found := false
+	err := client.listDeleted(dest, ListFilter{}, func(name string, _ *BlobItem) error {
+		found = found || name == dest
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("no soft-deleted blob %s found", dest)
+	}
+
+	return client.storageClient.Undelete(dest)
+}
+
+// UndeleteRecursive restores every soft-deleted blob below prefix that
+// passes filter. Blobs which could not be restored are returned as
+// BlobFailures.
+func (client *AzBlobstore) UndeleteRecursive(prefix string, filter ListFilter) error {
+	restored := startProgress("Undeleted", "blobs")
+
+	var mutex sync.Mutex
+	failures := &BlobFailures{Operation: "undelete", Errors: map[string]error{}}
+
+	var names []string
+	err := client.listDeleted(prefix, filter, func(name string, _ *BlobItem) error {
+		names = append(names, name)
+		return nil
+	})
+
+	forEachParallel(names, undeleteConcurrency, func(name string) {
+		if err := client.storageClient.Undelete(name); err != nil {
+			mutex.Lock()
+			failures.Errors[name] = err
+			mutex.Unlock()
+			return
+		}
+		restored.add(1)
+	})
+	restored.stop()
+
+	return failures.join(err)
+}
+
+// DryRunUndelete writes what Undelete would restore to out without
+// restoring anything.
+func (client *AzBlobstore) DryRunUndelete(dest string, out io.Writer) (DryRunSummary, error) {
+	preview := newDryRun("undelete", out)
+	err := client.listDeleted(dest, ListFilter{}, func(name string, item *BlobItem) error {
+		if name != dest {
+			return nil
+		}
+		return preview.add(item)
+	})
+	if err != nil {
+		return DryRunSummary{}, err
+	}
+	return preview.finish()
+}
+
+// DryRunUndeleteRecursive writes what UndeleteRecursive would restore to
+// out without restoring anything.
+func (client *AzBlobstore) DryRunUndeleteRecursive(prefix string, filter ListFilter, out io.Writer) (DryRunSummary, error) {
+	preview := newDryRun("undelete", out)
+	err := client.listDeleted(prefix, filter, func(_ string, item *BlobItem) error {
+		return preview.add(item)
+	})
+	if err != nil {
+		return DryRunSummary{}, err
+	}
+	return preview.finish()
+}
+
+// listDeleted calls handle once for every blob below prefix which has been
+// soft-deleted, skipping its soft-deleted snapshots.
+func (client *AzBlobstore) listDeleted(prefix string, filter ListFilter, handle func(name string, item *BlobItem) error) error {
+	if prefix != "" {
+		log.Printf("Looking for soft-deleted blobs with prefix '%s'\n", prefix)
+	} else {
+		log.Println("Looking for soft-deleted blobs")
+	}
+
+	_, err := client.List(prefix, filter, listDeleted, func(items []*BlobItem) error {
+		for _, item := range items {
+			isDeleted := item.Deleted != nil && *item.Deleted
+			isSnapshot := item.Snapshot != nil && *item.Snapshot != ""
+			if !isDeleted || isSnapshot {
+				continue
+			}
+			if err := handle(blobName(item), item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,96 @@
+package client
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+)
+
+type VerifyOptions struct {
+	// Concurrency is the number of blobs downloaded at the same time.
+	Concurrency int
+	// Sample is the percentage of blobs to verify, picked at random. Zero
+	// verifies every blob.
+	Sample float64
+}
+
+// VerifyReport is the machine readable outcome of Verify.
+type VerifyReport struct {
+	Prefix string `json:"prefix"`
+	// Verified counts the blobs whose content matched their Content-MD5.
+	Verified int64 `json:"verified"`
+	// Skipped counts the blobs which were not part of the sample.
+	Skipped int64 `json:"skipped"`
+	// Bytes is the size of all downloaded blobs.
+	Bytes int64 `json:"bytes"`
+	// Problems holds what is wrong with each blob that failed verification,
+	// keyed by blob name.
+	Problems map[string]string `json:"problems"`
+}
+
+// Verify downloads every blob below prefix that passes filter and checks
+// that its content matches the Content-MD5 stored with it. Blobs without a
+// Content-MD5 are reported as problems without being downloaded. Blobs
+// which failed verification are returned as BlobFailures.
+func (client *AzBlobstore) Verify(prefix string, filter ListFilter, options VerifyOptions) (VerifyReport, error) {
+	report := VerifyReport{Prefix: prefix, Problems: map[string]string{}}
+	if options.Sample < 0 || options.Sample > 100 {
+		return report, fmt.Errorf("invalid sample %g%%, expected a percentage between 0 and 100", options.Sample)
+	}
+
+	verified := startProgress("Verified", "blobs")
+
+	var mutex sync.Mutex
+	failures := &BlobFailures{Operation: "verify", Errors: map[string]error{}}
+
+	_, err := client.List(prefix, filter, ListOptions{}, func(items []*BlobItem) error {
+		forEachParallel(items, options.Concurrency, func(item *BlobItem) {
+			name := blobName(item)
+			props := itemProperties(item)
+
+			if options.Sample > 0 && rand.Float64()*100 >= options.Sample {
+				mutex.Lock()
+				report.Skipped++
+				mutex.Unlock()
+				return
+			}
+
+			err := client.verifyBlob(name, props.ContentMD5)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				failures.Errors[name] = err
+				report.Problems[name] = errorSummary(err)
+				return
+			}
+			report.Verified++
+			report.Bytes += props.ContentLength
+			verified.add(1)
+		})
+		return nil
+	})
+	verified.stop()
+
+	return report, failures.join(err)
+}
+
+// verifyBlob hashes the content of name while it is downloaded and checks
+// that its MD5 matches expectedMD5. Nothing is written to disk.
+func (client *AzBlobstore) verifyBlob(name string, expectedMD5 []byte) error {
+	if len(expectedMD5) == 0 {
+		return errors.New("the blob has no Content-MD5 to verify against")
+	}
+
+	hash := md5.New()
+	if err := client.downloadTo(name, hash); err != nil {
+		return err
+	}
+	if sum := hash.Sum(nil); !bytes.Equal(sum, expectedMD5) {
+		return fmt.Errorf("the downloaded MD5 %x does not match the stored Content-MD5 %x", sum, expectedMD5)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package client_test
+
+import (
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	azContainer "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client"
+	"github.com/cloudfoundry/bosh-azure-storage-cli/client/clientfakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Verify", func() {
+	var (
+		storageClient *clientfakes.FakeStorageClient
+		azBlobstore   client.AzBlobstore
+	)
+
+	item := func(name string, stored string) *client.BlobItem {
+		size := int64(len(stored))
+		var contentMD5 []byte
+		if stored != "" {
+			sum := md5.Sum([]byte(stored))
+			contentMD5 = sum[:]
+		}
+		return &client.BlobItem{
+			Name:       &name,
+			Properties: &azContainer.BlobProperties{ContentLength: &size, ContentMD5: contentMD5},
+		}
+	}
+
+	BeforeEach(func() {
+		storageClient = &clientfakes.FakeStorageClient{}
+		storageClient.ListStub = listPages([]*client.BlobItem{
+			item("stemcells/good", "good"),
+			item("stemcells/corrupt", "expected"),
+			item("stemcells/unhashed", ""),
+		})
+		storageClient.DownloadStreamStub = func(name string) (io.ReadCloser, error) {
+			content := map[string]string{"stemcells/good": "good", "stemcells/corrupt": "actual"}[name]
+			return io.NopCloser(strings.NewReader(content)), nil
+		}
+		azBlobstore, _ = client.New(storageClient) //nolint:errcheck
+	})
+
+	It("reports blobs whose content or Content-MD5 is wrong", func() {
+		report, err := azBlobstore.Verify("stemcells/", client.ListFilter{}, client.VerifyOptions{Concurrency: 2})
+		Expect(err).To(MatchError(ContainSubstring("failed to verify 2 blobs")))
+
+		Expect(report.Prefix).To(Equal("stemcells/"))
+		Expect(report.Verified).To(Equal(int64(1)))
+		Expect(report.Bytes).To(Equal(int64(4)))
+		Expect(report.Problems).To(HaveLen(2))
+		Expect(report.Problems["stemcells/corrupt"]).To(Equal(fmt.Sprintf(
+			"the downloaded MD5 %x does not match the stored Content-MD5 %x", md5.Sum([]byte("actual")), md5.Sum([]byte("expected")))))
+		Expect(report.Problems["stemcells/unhashed"]).To(Equal("the blob has no Content-MD5 to verify against"))
+
+		Expect(storageClient.DownloadStreamCallCount()).To(Equal(2))
+	})
+
+	It("reports blobs which could not be downloaded", func() {
+		storageClient.DownloadStreamStub = nil
+		storageClient.DownloadStreamReturns(nil, errors.New("boom"))
+
+		report, err := azBlobstore.Verify("stemcells/", client.ListFilter{}, client.VerifyOptions{})
+		Expect(err).To(HaveOccurred())
+		Expect(report.Problems["stemcells/good"]).To(Equal("boom"))
+	})
+
+	It("verifies every blob of a full sample", func() {
+		report, err := azBlobstore.Verify("stemcells/", client.ListFilter{Include: []string{"good"}}, client.VerifyOptions{Sample: 100})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Verified).To(Equal(int64(1)))
+		Expect(report.Skipped).To(BeZero())
+	})
+
+	It("rejects a sample outside of 0 to 100 percent", func() {
+		_, err := azBlobstore.Verify("", client.ListFilter{}, client.VerifyOptions{Sample: 150})
+		Expect(err).To(MatchError("invalid sample 150%, expected a percentage between 0 and 100"))
+	})
+})
+
+var _ = Describe("Verify through the blob service", func() {
+	It("hashes every blob while it is downloaded", func() {
+		service := newBlobService("account", "stemcells")
+		DeferCleanup(service.Close)
+		service.Put("ubuntu/a", "a")
+		service.Put("ubuntu/b", strings.Repeat("b", 1024*1024))
+		azBlobstore := service.Blobstore()
+
+		report, err := azBlobstore.Verify("ubuntu/", client.ListFilter{}, client.VerifyOptions{Concurrency: 2})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Verified).To(Equal(int64(2)))
+		Expect(report.Bytes).To(Equal(int64(1 + 1024*1024)))
+	})
+})
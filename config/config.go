@@ -3,14 +3,22 @@ package config
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net/url"
+	"strings"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
 )
 
 const storage cloud.ServiceName = "storage"
 
-var cloudConfig cloud.Configuration
+// cloudConfigs maps the supported environments to their cloud endpoints.
+var cloudConfigs = map[string]cloud.Configuration{
+	"AzureCloud":        cloud.AzurePublic,
+	"AzureChinaCloud":   cloud.AzureChina,
+	"AzureUSGovernment": cloud.AzureGovernment,
+}
 
 func init() {
 	// Configure the cloud endpoints for the storage service
@@ -32,6 +40,10 @@ type AZStorageConfig struct {
 	ContainerName string `json:"container_name"`
 	Environment   string `json:"environment"`
 	Timeout       string `json:"put_timeout_in_seconds"`
+	CopyTimeout   string `json:"copy_timeout_in_seconds"`
+	// ServiceURL overrides the blob service URL derived from the account
+	// name and environment, e.g. to use a local emulator such as Azurite.
+	ServiceURL string `json:"service_url"`
 }
 
 // NewFromReader returns a new azure-storage-cli configuration struct from the contents of reader.
@@ -53,23 +65,46 @@ func NewFromReader(reader io.Reader) (AZStorageConfig, error) {
 		return AZStorageConfig{}, err
 	}
 
+	err = config.checkServiceURL()
+	if err != nil {
+		return AZStorageConfig{}, err
+	}
+
 	return config, nil
 }
 
+// StorageEndpoint returns the blob service domain of the configured
+// environment. Every config carries its own environment, so that clients for
+// accounts in different clouds can be used side by side.
 func (c AZStorageConfig) StorageEndpoint() string {
-	return cloudConfig.Services[storage].Endpoint
+	return cloudConfigs[c.Environment].Services[storage].Endpoint
+}
+
+// AccountURL returns the blob service URL of the account, which is
+// ServiceURL if given.
+func (c AZStorageConfig) AccountURL() string {
+	if c.ServiceURL != "" {
+		return strings.TrimSuffix(c.ServiceURL, "/")
+	}
+	return fmt.Sprintf("https://%s.%s", c.AccountName, c.StorageEndpoint())
+}
+
+func (c *AZStorageConfig) checkServiceURL() error {
+	if c.ServiceURL == "" {
+		return nil
+	}
+	parsed, err := url.Parse(c.ServiceURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("invalid service_url, expected an http:// or https:// URL: " + c.ServiceURL)
+	}
+	return nil
 }
 
 func (c *AZStorageConfig) configureCloud() error {
-	switch c.Environment {
-	case "AzureCloud", "":
+	if c.Environment == "" {
 		c.Environment = "AzureCloud"
-		cloudConfig = cloud.AzurePublic
-	case "AzureChinaCloud":
-		cloudConfig = cloud.AzureChina
-	case "AzureUSGovernment":
-		cloudConfig = cloud.AzureGovernment
-	default:
+	}
+	if _, ok := cloudConfigs[c.Environment]; !ok {
 		return errors.New("unknown cloud environment: " + c.Environment)
 	}
 	return nil
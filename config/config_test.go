@@ -28,6 +28,19 @@ var _ = Describe("Config", func() {
 		Expect(config.StorageEndpoint()).To(Equal("blob.core.windows.net"))
 	})
 
+	It("contains the put and copy timeouts", func() {
+		configJson := []byte(`{"account_name": "foo-account-name",
+								"put_timeout_in_seconds": "30",
+								"copy_timeout_in_seconds": "3600"}`)
+		configReader := bytes.NewReader(configJson)
+
+		config, err := config.NewFromReader(configReader)
+
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.Timeout).To(Equal("30"))
+		Expect(config.CopyTimeout).To(Equal("3600"))
+	})
+
 	It("is empty if config cannot be parsed", func() {
 		configJson := []byte(`~`)
 		configReader := bytes.NewReader(configJson)
@@ -86,6 +99,36 @@ var _ = Describe("Config", func() {
 				Expect(config.StorageEndpoint()).To(Equal("blob.core.usgovcloudapi.net"))
 			})
 		})
+
+		It("keeps the endpoint of each config when several are loaded", func() {
+			public, err := config.NewFromReader(bytes.NewReader([]byte(`{}`)))
+			Expect(err).ToNot(HaveOccurred())
+			china, err := config.NewFromReader(bytes.NewReader([]byte(`{"environment": "AzureChinaCloud"}`)))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(public.StorageEndpoint()).To(Equal("blob.core.windows.net"))
+			Expect(china.StorageEndpoint()).To(Equal("blob.core.chinacloudapi.cn"))
+		})
+	})
+
+	Context("service URL", func() {
+		It("derives the account URL from the account name and environment", func() {
+			config, err := config.NewFromReader(bytes.NewReader([]byte(`{"account_name": "foo", "environment": "AzureChinaCloud"}`)))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.AccountURL()).To(Equal("https://foo.blob.core.chinacloudapi.cn"))
+		})
+
+		It("uses the service_url if given", func() {
+			config, err := config.NewFromReader(bytes.NewReader([]byte(`{"account_name": "devstoreaccount1",
+																		"service_url": "http://127.0.0.1:10000/devstoreaccount1/"}`)))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.AccountURL()).To(Equal("http://127.0.0.1:10000/devstoreaccount1"))
+		})
+
+		It("rejects a service_url which is not an http URL", func() {
+			_, err := config.NewFromReader(bytes.NewReader([]byte(`{"service_url": "127.0.0.1:10000"}`)))
+			Expect(err).To(MatchError("invalid service_url, expected an http:// or https:// URL: 127.0.0.1:10000"))
+		})
 	})
 })
 
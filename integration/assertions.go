@@ -290,7 +290,7 @@ func AssertOnListDeleteLifecyle(cliPath string, cfg *config.AZStorageConfig) {
 	configPath := MakeConfigFile(cfg)
 	defer os.Remove(configPath) //nolint:errcheck
 
-	cli, err := RunCli(cliPath, configPath, "delete-recursive", "")
+	cli, err := RunCli(cliPath, configPath, "delete-recursive", "--all", "")
 	Expect(err).ToNot(HaveOccurred())
 	Expect(cli.ExitCode()).To(BeZero())
 	cliSession, err := RunCli(cliPath, configPath, "list")
@@ -319,6 +319,16 @@ func AssertOnListDeleteLifecyle(cliPath string, cfg *config.AZStorageConfig) {
 	Expect(cliSession.ExitCode()).To(BeZero())
 	Expect(len(bytes.FieldsFunc(cliSession.Out.Contents(), func(r rune) bool { return r == '\n' || r == '\r' }))).To(BeNumerically("==", 4))
 
+	// Assert that a dry run only reports the blobs with custom prefix
+	cliSession, err = RunCli(cliPath, configPath, "delete-recursive", "--dry-run", customPrefix)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(cliSession.ExitCode()).To(BeZero())
+	Expect(string(cliSession.Out.Contents())).To(ContainSubstring("would delete 4 blobs"))
+
+	cliSession, err = RunCli(cliPath, configPath, "list", customPrefix)
+	Expect(err).ToNot(HaveOccurred())
+	Expect(len(bytes.FieldsFunc(cliSession.Out.Contents(), func(r rune) bool { return r == '\n' || r == '\r' }))).To(BeNumerically("==", 4))
+
 	// Delete all blobs with custom prefix
 	cliSession, err = RunCli(cliPath, configPath, "delete-recursive", customPrefix)
 	Expect(err).ToNot(HaveOccurred())
@@ -336,9 +346,15 @@ func AssertOnListDeleteLifecyle(cliPath string, cfg *config.AZStorageConfig) {
 	Expect(cliSession.ExitCode()).To(BeZero())
 	Expect(len(bytes.FieldsFunc(cliSession.Out.Contents(), func(r rune) bool { return r == '\n' || r == '\r' }))).To(BeNumerically("==", 2))
 
-	// Delete all other blobs
+	// Assert that deleting the whole container needs to be confirmed
 	cliSession, err = RunCli(cliPath, configPath, "delete-recursive", "")
 	Expect(err).ToNot(HaveOccurred())
+	Expect(cliSession.ExitCode()).ToNot(BeZero())
+	Expect(string(cliSession.Err.Contents())).To(ContainSubstring("pass --all to confirm"))
+
+	// Delete all other blobs
+	cliSession, err = RunCli(cliPath, configPath, "delete-recursive", "--all", "")
+	Expect(err).ToNot(HaveOccurred())
 	Expect(cliSession.ExitCode()).To(BeZero())
 
 	// Assert that all blobs are deleted
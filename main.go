@@ -1,10 +1,20 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"math"
 	"os"
+	"os/exec"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/cloudfoundry/bosh-azure-storage-cli/client"
@@ -13,6 +23,19 @@ import (
 
 var version string
 
+// interruptible lists the commands which stop on an interrupt: running
+// copies are aborted and no further blobs are started. A second interrupt
+// terminates the process.
+var interruptible = map[string]bool{
+	"copy":             true,
+	"copy-recursive":   true,
+	"mirror":           true,
+	"move":             true,
+	"move-recursive":   true,
+	"promote-snapshot": true,
+	"restore-version":  true,
+}
+
 func main() {
 
 	configPath := flag.String("c", "", "configuration path")
@@ -24,49 +47,109 @@ func main() {
 		os.Exit(0)
 	}
 
-	configFile, err := os.Open(*configPath)
-	if err != nil {
-		log.Fatalln(err)
+	nonFlagArgs := flag.Args()
+	cmd := nonFlagArgs[0]
+
+	ctx := context.Background()
+	if interruptible[cmd] {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, os.Interrupt)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			stop()
+		}()
 	}
 
-	azConfig, err := config.NewFromReader(configFile)
-	if err != nil {
-		log.Fatalln(err)
+	if cmd == "mirror" {
+		// mirror works on two accounts and loads both configs itself.
+		mirror(ctx, *configPath, nonFlagArgs[1:])
+		return
 	}
 
-	storageClient, err := client.NewStorageClient(azConfig)
+	azConfig, err := loadConfig(*configPath)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	blobstoreClient, err := client.New(storageClient)
+	blobstoreClient, err := newBlobstore(azConfig)
 	if err != nil {
 		log.Fatalln(err)
 	}
 
-	nonFlagArgs := flag.Args()
-	cmd := nonFlagArgs[0]
-
 	switch cmd {
 	case "put":
-		if len(nonFlagArgs) != 3 {
-			log.Fatalf("Put method expected 3 arguments got %d\n", len(nonFlagArgs))
+		putFlags := flag.NewFlagSet("put", flag.ExitOnError)
+		leaseID := putFlags.String("lease-id", "", "ID of the active lease on the destination blob")
+		recursive := putFlags.Bool("recursive", false, "upload all files below a directory to their relative path below the prefix")
+		crc64 := putFlags.Bool("crc64", false, "upload in blocks validated by the service against their CRC64")
+		sha256 := putFlags.Bool("sha256", false, "store the SHA-256 of the file in the sha256 metadata of the blob")
+		concurrency := putFlags.Int("concurrency", 8, "number of files uploaded at the same time with --recursive")
+		var exclude stringList
+		putFlags.Var(&exclude, "exclude", "skip paths matching this glob pattern with --recursive (repeatable)")
+		putFlags.Parse(nonFlagArgs[1:]) //nolint:errcheck
+
+		putArgs := putFlags.Args()
+		if len(putArgs) != 2 {
+			log.Fatalf("Put method expected 2 arguments got %d\n", len(putArgs))
+		}
+		sourceFilePath, dst := putArgs[0], putArgs[1]
+
+		if *recursive {
+			if *leaseID != "" || *crc64 || *sha256 {
+				log.Fatalln("--lease-id, --crc64 and --sha256 cannot be used with --recursive")
+			}
+			var summary client.TransferSummary
+			summary, err = blobstoreClient.PutRecursive(sourceFilePath, dst, client.PutRecursiveOptions{
+				Concurrency: *concurrency,
+				Exclude:     exclude,
+			})
+			fmt.Println(summary)
+			fatalLog(cmd, err)
+			break
+		}
+		if len(exclude) > 0 {
+			log.Fatalln("--exclude can only be used with --recursive")
 		}
-		sourceFilePath, dst := nonFlagArgs[1], nonFlagArgs[2]
 
 		_, err := os.Stat(sourceFilePath)
 		if err != nil {
 			log.Fatalln(err)
 		}
 
-		err = blobstoreClient.Put(sourceFilePath, dst)
+		err = blobstoreClient.Put(sourceFilePath, dst, client.UploadOptions{LeaseID: *leaseID, CRC64: *crc64, SHA256: *sha256})
 		fatalLog(cmd, err)
 
 	case "get":
-		if len(nonFlagArgs) != 3 {
-			log.Fatalf("Get method expected 3 arguments got %d\n", len(nonFlagArgs))
+		getFlags := flag.NewFlagSet("get", flag.ExitOnError)
+		snapshot := getFlags.String("snapshot", "", "download the snapshot with this timestamp instead of the blob")
+		versionID := getFlags.String("version-id", "", "download this previous version instead of the current blob")
+		crc64 := getFlags.Bool("crc64", false, "download in ranges validated against the CRC64 of the service and verify the Content-MD5")
+		sha256 := getFlags.Bool("sha256", false, "verify the download against the sha256 metadata of the blob")
+		recursive := getFlags.Bool("recursive", false, "download all blobs below the prefix to their relative path below a directory")
+		concurrency := getFlags.Int("concurrency", 8, "number of blobs downloaded at the same time with --recursive")
+		filter := addFilterFlags(getFlags)
+		getFlags.Parse(nonFlagArgs[1:]) //nolint:errcheck
+
+		getArgs := getFlags.Args()
+		if len(getArgs) != 2 {
+			log.Fatalf("Get method expected 2 arguments got %d\n", len(getArgs))
+		}
+		src, dst := getArgs[0], getArgs[1]
+
+		if *recursive {
+			if *snapshot != "" || *versionID != "" || *crc64 || *sha256 {
+				log.Fatalln("--snapshot, --version-id, --crc64 and --sha256 cannot be used with --recursive")
+			}
+			var summary client.TransferSummary
+			summary, err = blobstoreClient.GetRecursive(src, dst, filter(), client.GetRecursiveOptions{Concurrency: *concurrency})
+			fmt.Println(summary)
+			fatalLog(cmd, err)
+			break
+		}
+		if anyFlagSet(getFlags, filterFlagNames...) {
+			log.Fatalln("--include, --exclude, --min-size, --max-size, --older-than and --newer-than can only be used with --recursive")
 		}
-		src, dst := nonFlagArgs[1], nonFlagArgs[2]
 
 		var dstFile *os.File
 		dstFile, err = os.Create(dst)
@@ -76,39 +159,387 @@ func main() {
 
 		defer dstFile.Close() //nolint:errcheck
 
-		err = blobstoreClient.Get(src, dstFile)
+		err = blobstoreClient.Get(src, dstFile, client.DownloadOptions{
+			Snapshot:  *snapshot,
+			VersionID: *versionID,
+			CRC64:     *crc64,
+			SHA256:    *sha256,
+		})
 		fatalLog(cmd, err)
 
 	case "copy":
-		if len(nonFlagArgs) != 3 {
-			log.Fatalf("Get method expected 3 arguments got %d\n", len(nonFlagArgs))
+		copyFlags := flag.NewFlagSet("copy", flag.ExitOnError)
+		sourceConfig := copyFlags.String("source-config", "", "configuration of the storage account to copy from")
+		sourceContainer := copyFlags.String("source-container", "", "container to copy from instead of the configured one")
+		copyOptions := addCopyFlags(copyFlags)
+		copyFlags.Parse(nonFlagArgs[1:]) //nolint:errcheck
+
+		var options client.CopyOptions
+		options, err = copyOptions()
+		if err != nil {
+			log.Fatalln(err)
 		}
 
-		srcBlob, dstBlob := nonFlagArgs[1], nonFlagArgs[2]
+		copyArgs := copyFlags.Args()
+		if len(copyArgs) != 2 {
+			log.Fatalf("Copy method expected 2 arguments got %d\n", len(copyArgs))
+		}
+		srcBlob, dstBlob := copyArgs[0], copyArgs[1]
 
-		err = blobstoreClient.Copy(srcBlob, dstBlob)
+		switch {
+		case strings.Contains(srcBlob, "://"):
+			if *sourceConfig != "" || *sourceContainer != "" {
+				log.Fatalln("--source-config and --source-container cannot be used with a source URL")
+			}
+			err = blobstoreClient.CopyFromURL(ctx, srcBlob, dstBlob, options)
+		case *sourceConfig != "" || *sourceContainer != "":
+			var sourceClient *client.AzBlobstore
+			sourceClient, err = sourceBlobstore(azConfig, *sourceConfig, *sourceContainer)
+			if err != nil {
+				log.Fatalln(err)
+			}
+			err = blobstoreClient.CopyFrom(ctx, sourceClient, srcBlob, dstBlob, options)
+		default:
+			err = blobstoreClient.Copy(ctx, srcBlob, dstBlob, options)
+		}
 		fatalLog(cmd, err)
 
 	case "delete":
-		if len(nonFlagArgs) != 2 {
-			log.Fatalf("Delete method expected 2 arguments got %d\n", len(nonFlagArgs))
+		deleteFlags := flag.NewFlagSet("delete", flag.ExitOnError)
+		dryRun := deleteFlags.Bool("dry-run", false, "print what would be deleted without deleting it")
+		snapshots := deleteFlags.String("snapshots", "", "'include' to delete the blob with its snapshots, 'only' to delete just its snapshots")
+		leaseID := deleteFlags.String("lease-id", "", "ID of the active lease on the blob")
+		deleteFlags.Parse(nonFlagArgs[1:]) //nolint:errcheck
+
+		deleteArgs := deleteFlags.Args()
+		if len(deleteArgs) != 1 {
+			log.Fatalf("Delete method expected 1 argument got %d\n", len(deleteArgs))
+		}
+		if *snapshots != "" && *snapshots != "include" && *snapshots != "only" {
+			log.Fatalf("Invalid --snapshots '%s', expected 'include' or 'only'\n", *snapshots)
 		}
 
-		err = blobstoreClient.Delete(nonFlagArgs[1])
+		deleteOptions := client.DeleteOptions{Snapshots: *snapshots, LeaseID: *leaseID}
+		if *dryRun {
+			_, err = blobstoreClient.DryRunDelete(deleteArgs[0], deleteOptions, os.Stdout)
+		} else {
+			err = blobstoreClient.Delete(deleteArgs[0], deleteOptions)
+		}
 		fatalLog(cmd, err)
 
 	case "delete-recursive":
+		deleteFlags := flag.NewFlagSet("delete-recursive", flag.ExitOnError)
+		concurrency := deleteFlags.Int("concurrency", 4, "number of batches of blobs deleted at the same time")
+		continueOnError := deleteFlags.Bool("continue-on-error", false, "keep deleting the remaining blobs when a blob cannot be deleted")
+		dryRun := deleteFlags.Bool("dry-run", false, "print what would be deleted without deleting it")
+		all := deleteFlags.Bool("all", false, "allow deleting the whole container when no prefix is given")
+		filter := addFilterFlags(deleteFlags)
+		deleteFlags.Parse(nonFlagArgs[1:]) //nolint:errcheck
+
+		deleteArgs := deleteFlags.Args()
 		var prefix string
-		if len(nonFlagArgs) > 2 {
-			log.Fatalf("delete-recursive takes at most one argument (prefix) got %d\n", len(nonFlagArgs)-1)
-		} else if len(nonFlagArgs) == 2 {
-			prefix = nonFlagArgs[1]
+		if len(deleteArgs) > 1 {
+			log.Fatalf("delete-recursive takes at most one argument (prefix) got %d\n", len(deleteArgs))
+		} else if len(deleteArgs) == 1 {
+			prefix = deleteArgs[0]
 		} else {
 			prefix = ""
 		}
-		err = blobstoreClient.DeleteRecursive(prefix)
+		if *dryRun {
+			_, err = blobstoreClient.DryRunDeleteRecursive(prefix, filter(), os.Stdout)
+			fatalLog("delete-recursive", err)
+			break
+		}
+
+		if prefix == "" && !*all {
+			log.Fatalln("delete-recursive without a prefix deletes the whole container, pass --all to confirm or --dry-run to preview")
+		}
+
+		err = blobstoreClient.DeleteRecursive(prefix, filter(), client.DeleteRecursiveOptions{
+			Concurrency:     *concurrency,
+			ContinueOnError: *continueOnError,
+		})
 		fatalLog("delete-recursive", err)
 
+	case "copy-recursive":
+		copyFlags := flag.NewFlagSet("copy-recursive", flag.ExitOnError)
+		sourceConfig := copyFlags.String("source-config", "", "configuration of the storage account to copy from")
+		sourceContainer := copyFlags.String("source-container", "", "container to copy from instead of the configured one")
+		concurrency := copyFlags.Int("concurrency", 16, "number of blobs copied at the same time")
+		continueOnError := copyFlags.Bool("continue-on-error", false, "keep copying the remaining blobs when a blob cannot be copied")
+		copyOptions := addCopyFlags(copyFlags)
+		filter := addFilterFlags(copyFlags)
+		copyFlags.Parse(nonFlagArgs[1:]) //nolint:errcheck
+
+		copyArgs := copyFlags.Args()
+		if len(copyArgs) != 2 {
+			log.Fatalf("copy-recursive expected 2 arguments (source and destination prefix) got %d\n", len(copyArgs))
+		}
+
+		options := client.CopyRecursiveOptions{
+			Concurrency:     *concurrency,
+			ContinueOnError: *continueOnError,
+		}
+		options.Copy, err = copyOptions()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		options.Source, err = sourceBlobstore(azConfig, *sourceConfig, *sourceContainer)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		var summary client.TransferSummary
+		summary, err = blobstoreClient.CopyRecursive(ctx, copyArgs[0], copyArgs[1], filter(), options)
+		fmt.Println(summary)
+		fatalLog(cmd, err)
+
+	case "sync":
+		if len(nonFlagArgs) < 2 || nonFlagArgs[1] != "up" && nonFlagArgs[1] != "down" {
+			log.Fatalf("sync expected 'up <local-dir> <prefix>' or 'down <prefix> <local-dir>'\n")
+		}
+		direction := nonFlagArgs[1]
+
+		syncFlags := flag.NewFlagSet("sync "+direction, flag.ExitOnError)
+		concurrency := syncFlags.Int("concurrency", 8, "number of files transferred at the same time")
+		deleteExtra := syncFlags.Bool("delete", false, "delete files or blobs at the destination which do not exist at the source")
+		all := syncFlags.Bool("all", false, "confirm --delete without a prefix, which syncs against the whole container")
+		dryRun := syncFlags.Bool("dry-run", false, "print what would be transferred and deleted without changing anything")
+		var exclude stringList
+		syncFlags.Var(&exclude, "exclude", "skip paths matching this glob pattern (repeatable)")
+		syncFlags.Parse(nonFlagArgs[2:]) //nolint:errcheck
+
+		syncArgs := syncFlags.Args()
+		if len(syncArgs) != 2 {
+			log.Fatalf("sync %s expected 2 arguments got %d\n", direction, len(syncArgs))
+		}
+
+		options := client.SyncOptions{Concurrency: *concurrency, Delete: *deleteExtra, Exclude: exclude, All: *all}
+		switch {
+		case direction == "up" && *dryRun:
+			_, err = blobstoreClient.DryRunSyncUp(syncArgs[0], syncArgs[1], options, os.Stdout)
+		case direction == "up":
+			var summary client.TransferSummary
+			summary, err = blobstoreClient.SyncUp(syncArgs[0], syncArgs[1], options)
+			fmt.Println(summary)
+		case *dryRun:
+			_, err = blobstoreClient.DryRunSyncDown(syncArgs[0], syncArgs[1], options, os.Stdout)
+		default:
+			var summary client.TransferSummary
+			summary, err = blobstoreClient.SyncDown(syncArgs[0], syncArgs[1], options)
+			fmt.Println(summary)
+		}
+		fatalLog(cmd, err)
+
+	case "move":
+		moveFlags := flag.NewFlagSet("move", flag.ExitOnError)
+		dryRun := moveFlags.Bool("dry-run", false, "print what would be moved without moving it")
+		moveFlags.Parse(nonFlagArgs[1:]) //nolint:errcheck
+
+		moveArgs := moveFlags.Args()
+		if len(moveArgs) != 2 {
+			log.Fatalf("Move method expected 2 arguments got %d\n", len(moveArgs))
+		}
+
+		if *dryRun {
+			_, err = blobstoreClient.DryRunMove(moveArgs[0], moveArgs[1], os.Stdout)
+		} else {
+			err = blobstoreClient.Move(ctx, moveArgs[0], moveArgs[1])
+		}
+		fatalLog(cmd, err)
+
+	case "move-recursive":
+		moveFlags := flag.NewFlagSet("move-recursive", flag.ExitOnError)
+		concurrency := moveFlags.Int("concurrency", 8, "number of blobs moved at the same time")
+		continueOnError := moveFlags.Bool("continue-on-error", false, "keep moving the remaining blobs when a blob cannot be moved")
+		dryRun := moveFlags.Bool("dry-run", false, "print what would be moved without moving it")
+		filter := addFilterFlags(moveFlags)
+		moveFlags.Parse(nonFlagArgs[1:]) //nolint:errcheck
+
+		moveArgs := moveFlags.Args()
+		if len(moveArgs) != 2 {
+			log.Fatalf("move-recursive expected 2 arguments (source and destination prefix) got %d\n", len(moveArgs))
+		}
+
+		if *dryRun {
+			_, err = blobstoreClient.DryRunMoveRecursive(moveArgs[0], moveArgs[1], filter(), os.Stdout)
+		} else {
+			err = blobstoreClient.MoveRecursive(ctx, moveArgs[0], moveArgs[1], filter(), client.MoveRecursiveOptions{
+				Concurrency:     *concurrency,
+				ContinueOnError: *continueOnError,
+			})
+		}
+		fatalLog(cmd, err)
+
+	case "versions":
+		versionsFlags := flag.NewFlagSet("versions", flag.ExitOnError)
+		long := versionsFlags.Bool("long", false, "print size, last-modified, tier and ETag of each version")
+		jsonOutput := versionsFlags.Bool("json", false, "print one JSON object per version")
+		versionsFlags.Parse(nonFlagArgs[1:]) //nolint:errcheck
+
+		versionsArgs := versionsFlags.Args()
+		if len(versionsArgs) != 1 {
+			log.Fatalf("Versions method expected 1 argument got %d\n", len(versionsArgs))
+		}
+
+		format := client.ListFormatNames
+		if *long {
+			format = client.ListFormatLong
+		} else if *jsonOutput {
+			format = client.ListFormatJSON
+		}
+
+		var versions []*client.BlobItem
+		versions, err = blobstoreClient.Versions(versionsArgs[0])
+		fatalLog(cmd, err)
+
+		listWriter := client.NewListWriter(os.Stdout, format)
+		for _, version := range versions {
+			err = listWriter.Write(version)
+			fatalLog(cmd, err)
+		}
+		err = listWriter.Flush()
+		fatalLog(cmd, err)
+
+	case "restore-version":
+		if len(nonFlagArgs) != 3 {
+			log.Fatalf("Restore-version method expected 3 arguments got %d\n", len(nonFlagArgs))
+		}
+
+		err = blobstoreClient.RestoreVersion(ctx, nonFlagArgs[1], nonFlagArgs[2])
+		fatalLog(cmd, err)
+
+	case "undelete":
+		undeleteFlags := flag.NewFlagSet("undelete", flag.ExitOnError)
+		dryRun := undeleteFlags.Bool("dry-run", false, "print what would be restored without restoring it")
+		undeleteFlags.Parse(nonFlagArgs[1:]) //nolint:errcheck
+
+		undeleteArgs := undeleteFlags.Args()
+		if len(undeleteArgs) != 1 {
+			log.Fatalf("Undelete method expected 1 argument got %d\n", len(undeleteArgs))
+		}
+
+		if *dryRun {
+			_, err = blobstoreClient.DryRunUndelete(undeleteArgs[0], os.Stdout)
+		} else {
+			err = blobstoreClient.Undelete(undeleteArgs[0])
+		}
+		fatalLog(cmd, err)
+
+	case "undelete-recursive":
+		undeleteFlags := flag.NewFlagSet("undelete-recursive", flag.ExitOnError)
+		dryRun := undeleteFlags.Bool("dry-run", false, "print what would be restored without restoring it")
+		filter := addFilterFlags(undeleteFlags)
+		undeleteFlags.Parse(nonFlagArgs[1:]) //nolint:errcheck
+
+		undeleteArgs := undeleteFlags.Args()
+		var prefix string
+		if len(undeleteArgs) > 1 {
+			log.Fatalf("undelete-recursive takes at most one argument (prefix) got %d\n", len(undeleteArgs))
+		} else if len(undeleteArgs) == 1 {
+			prefix = undeleteArgs[0]
+		}
+
+		if *dryRun {
+			_, err = blobstoreClient.DryRunUndeleteRecursive(prefix, filter(), os.Stdout)
+		} else {
+			err = blobstoreClient.UndeleteRecursive(prefix, filter())
+		}
+		fatalLog(cmd, err)
+
+	case "snapshot":
+		if len(nonFlagArgs) != 2 {
+			log.Fatalf("Snapshot method expected 2 arguments got %d\n", len(nonFlagArgs))
+		}
+
+		var snapshot string
+		snapshot, err = blobstoreClient.Snapshot(nonFlagArgs[1])
+		fatalLog(cmd, err)
+
+		fmt.Println(snapshot)
+
+	case "promote-snapshot":
+		if len(nonFlagArgs) != 3 {
+			log.Fatalf("Promote-snapshot method expected 3 arguments got %d\n", len(nonFlagArgs))
+		}
+
+		err = blobstoreClient.PromoteSnapshot(ctx, nonFlagArgs[1], nonFlagArgs[2])
+		fatalLog(cmd, err)
+
+	case "lease":
+		if len(nonFlagArgs) < 2 {
+			log.Fatalf("lease expected a subcommand (acquire, renew, release, break, change)\n")
+		}
+		leaseCmd := nonFlagArgs[1]
+		leaseFlags := flag.NewFlagSet("lease "+leaseCmd, flag.ExitOnError)
+		duration := leaseFlags.String("duration", "60", "lease duration in seconds (15 to 60) or 'infinite'")
+		proposedID := leaseFlags.String("proposed-id", "", "lease ID to acquire the lease with instead of a service generated one")
+		breakPeriod := leaseFlags.Int("break-period", -1, "seconds (0 to 60) until the lease is broken, defaults to the remaining lease period")
+		leaseFlags.Parse(nonFlagArgs[2:]) //nolint:errcheck
+
+		leaseArgs := leaseFlags.Args()
+		expectedArgs := map[string]int{"acquire": 1, "renew": 2, "release": 2, "break": 1, "change": 3}
+		expected, ok := expectedArgs[leaseCmd]
+		if !ok {
+			log.Fatalf("Unknown lease subcommand '%s', expected acquire, renew, release, break or change\n", leaseCmd)
+		}
+		if len(leaseArgs) != expected {
+			log.Fatalf("lease %s expected %d arguments got %d\n", leaseCmd, expected, len(leaseArgs))
+		}
+
+		switch leaseCmd {
+		case "acquire":
+			var seconds int64 = client.InfiniteLease
+			if *duration != "infinite" {
+				seconds, err = strconv.ParseInt(*duration, 10, 32)
+				if err != nil {
+					log.Fatalf("Invalid --duration '%s', expected seconds or 'infinite'\n", *duration)
+				}
+			}
+			var leaseID string
+			leaseID, err = blobstoreClient.AcquireLease(leaseArgs[0], int32(seconds), *proposedID)
+			if err == nil {
+				fmt.Println(leaseID)
+			}
+		case "renew":
+			err = blobstoreClient.RenewLease(leaseArgs[0], leaseArgs[1])
+		case "release":
+			err = blobstoreClient.ReleaseLease(leaseArgs[0], leaseArgs[1])
+		case "break":
+			var remaining int32
+			remaining, err = blobstoreClient.BreakLease(leaseArgs[0], int32(*breakPeriod))
+			if err == nil {
+				log.Printf("Lease breaks in %d seconds", remaining)
+			}
+		case "change":
+			var leaseID string
+			leaseID, err = blobstoreClient.ChangeLease(leaseArgs[0], leaseArgs[1], leaseArgs[2])
+			if err == nil {
+				fmt.Println(leaseID)
+			}
+		}
+		fatalLog(cmd, err)
+
+	case "lock":
+		lockFlags := flag.NewFlagSet("lock", flag.ExitOnError)
+		duration := lockFlags.Int("duration", 60, "lease duration in seconds (15 to 60), after which a crashed holder loses the lock")
+		timeout := lockFlags.Duration("timeout", 0, "give up waiting for the lock after this long, e.g. 10m (default wait forever)")
+		lockFlags.Parse(nonFlagArgs[1:]) //nolint:errcheck
+
+		lockArgs := lockFlags.Args()
+		if len(lockArgs) > 1 && lockArgs[1] == "--" {
+			lockArgs = append(lockArgs[:1], lockArgs[2:]...)
+		}
+		if len(lockArgs) < 2 {
+			log.Fatalf("lock expected a lock name and a command got %d arguments\n", len(lockArgs))
+		}
+
+		var lock *client.Lock
+		lock, err = blobstoreClient.NewLock(lockArgs[0], client.LockOptions{Duration: int32(*duration), Timeout: *timeout})
+		fatalLog(cmd, err)
+
+		os.Exit(runLocked(lock, lockArgs[1], lockArgs[2:]))
+
 	case "exists":
 		if len(nonFlagArgs) != 2 {
 			log.Fatalf("Exists method expected 2 arguments got %d\n", len(nonFlagArgs))
@@ -149,24 +580,174 @@ func main() {
 		os.Exit(0)
 
 	case "list":
+		listFlags := flag.NewFlagSet("list", flag.ExitOnError)
+		long := listFlags.Bool("long", false, "print size, last-modified, tier and ETag of each blob")
+		jsonOutput := listFlags.Bool("json", false, "print one JSON object per blob")
+		maxResults := listFlags.Int("max-results", 0, "stop after this many blobs and print a continuation token")
+		continuationToken := listFlags.String("continuation-token", "", "resume a previous listing from its continuation token")
+		with := listFlags.String("with", "", "comma separated datasets to include: snapshots,versions,deleted,metadata,tags,uncommittedblobs")
+		filter := addFilterFlags(listFlags)
+		listFlags.Parse(nonFlagArgs[1:]) //nolint:errcheck
+
+		if *long && *jsonOutput {
+			log.Fatalln("List method accepts only one of --long and --json")
+		}
+		if *maxResults < 0 || *maxResults > math.MaxInt32 {
+			log.Fatalf("Invalid --max-results %d\n", *maxResults)
+		}
+
+		listArgs := listFlags.Args()
 		var prefix string
 
-		if len(nonFlagArgs) == 1 {
+		if len(listArgs) == 0 {
 			prefix = ""
-		} else if len(nonFlagArgs) == 2 {
-			prefix = nonFlagArgs[1]
+		} else if len(listArgs) == 1 {
+			prefix = listArgs[0]
 		} else {
-			log.Fatalf("List method expected 1 or 2 arguments, got %d\n", len(nonFlagArgs)-1)
+			log.Fatalf("List method expected at most 1 argument (prefix), got %d\n", len(listArgs))
 		}
 
-		var objects []string
-		objects, err = blobstoreClient.List(prefix)
+		format := client.ListFormatNames
+		if *long {
+			format = client.ListFormatLong
+		} else if *jsonOutput {
+			format = client.ListFormatJSON
+		}
+
+		include, err := client.ParseListInclude(*with)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		listWriter := client.NewListWriter(os.Stdout, format)
+		listOptions := client.ListOptions{
+			MaxResults: int32(*maxResults),
+			Marker:     *continuationToken,
+			Include:    include,
+		}
+
+		var nextToken string
+		nextToken, err = blobstoreClient.List(prefix, filter(), listOptions, func(objects []*client.BlobItem) error {
+			for _, object := range objects {
+				if err := listWriter.Write(object); err != nil {
+					return err
+				}
+			}
+			return listWriter.Flush()
+		})
 		if err != nil {
 			log.Fatalf("Failed to list objects: %s", err)
 		}
 
-		for _, object := range objects {
-			fmt.Println(object)
+		if nextToken != "" {
+			log.Printf("Continuation token: %s\n", nextToken)
+		}
+
+	case "du":
+		duFlags := flag.NewFlagSet("du", flag.ExitOnError)
+		depth := duFlags.Int("depth", 1, "number of '/' separated prefix levels to report on")
+		byTier := duFlags.Bool("by-tier", false, "break totals down by access tier")
+		jsonOutput := duFlags.Bool("json", false, "print the summary as JSON")
+		duFlags.Parse(nonFlagArgs[1:]) //nolint:errcheck
+
+		duArgs := duFlags.Args()
+		if len(duArgs) > 1 {
+			log.Fatalf("du takes at most one argument (prefix) got %d\n", len(duArgs))
+		}
+		if *depth < 0 {
+			log.Fatalf("Invalid --depth %d\n", *depth)
+		}
+
+		var prefix string
+		if len(duArgs) == 1 {
+			prefix = duArgs[0]
+		}
+
+		var usage *client.DiskUsage
+		usage, err = blobstoreClient.DiskUsage(prefix, client.DiskUsageOptions{Depth: *depth, ByTier: *byTier})
+		fatalLog(cmd, err)
+
+		if *jsonOutput {
+			err = json.NewEncoder(os.Stdout).Encode(usage)
+		} else {
+			err = usage.WriteText(os.Stdout)
+		}
+		fatalLog(cmd, err)
+
+	case "verify":
+		verifyFlags := flag.NewFlagSet("verify", flag.ExitOnError)
+		concurrency := verifyFlags.Int("concurrency", 8, "number of blobs downloaded at the same time")
+		sample := verifyFlags.String("sample", "", "verify only this percentage of the blobs picked at random, e.g. 10%")
+		filter := addFilterFlags(verifyFlags)
+		verifyFlags.Parse(nonFlagArgs[1:]) //nolint:errcheck
+
+		verifyArgs := verifyFlags.Args()
+		if len(verifyArgs) > 1 {
+			log.Fatalf("verify takes at most one argument (prefix) got %d\n", len(verifyArgs))
+		}
+		var prefix string
+		if len(verifyArgs) == 1 {
+			prefix = verifyArgs[0]
+		}
+
+		var percentage float64
+		if *sample != "" {
+			percentage, err = strconv.ParseFloat(strings.TrimSuffix(*sample, "%"), 64)
+			if err != nil || percentage <= 0 || percentage > 100 {
+				log.Fatalf("Invalid --sample '%s', expected a percentage such as 10%%\n", *sample)
+			}
+		}
+
+		var report client.VerifyReport
+		report, err = blobstoreClient.Verify(prefix, filter(), client.VerifyOptions{Concurrency: *concurrency, Sample: percentage})
+		if encodeErr := json.NewEncoder(os.Stdout).Encode(report); err == nil {
+			err = encodeErr
+		}
+		fatalLog(cmd, err)
+
+	case "manifest":
+		if len(nonFlagArgs) != 3 && !(len(nonFlagArgs) == 2 && nonFlagArgs[1] == "create") {
+			log.Fatalf("manifest expected 'create [prefix]' or 'verify <manifest.json>'\n")
+		}
+
+		switch nonFlagArgs[1] {
+		case "create":
+			var prefix string
+			if len(nonFlagArgs) == 3 {
+				prefix = nonFlagArgs[2]
+			}
+			var manifest client.Manifest
+			manifest, err = blobstoreClient.CreateManifest(prefix)
+			fatalLog(cmd, err)
+
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(manifest)
+			fatalLog(cmd, err)
+		case "verify":
+			var manifestFile *os.File
+			manifestFile, err = os.Open(nonFlagArgs[2])
+			if err != nil {
+				log.Fatalln(err)
+			}
+			var manifest client.Manifest
+			err = json.NewDecoder(manifestFile).Decode(&manifest)
+			manifestFile.Close() //nolint:errcheck
+			if err != nil {
+				log.Fatalf("Invalid manifest %s: %s\n", nonFlagArgs[2], err)
+			}
+
+			var diff client.ManifestDiff
+			diff, err = blobstoreClient.VerifyManifest(manifest)
+			fatalLog(cmd, err)
+
+			err = json.NewEncoder(os.Stdout).Encode(diff)
+			fatalLog(cmd, err)
+			if !diff.Matches() {
+				log.Fatalf("The blobstore does not match the manifest: %s\n", diff)
+			}
+		default:
+			log.Fatalf("Unknown manifest subcommand '%s', expected create or verify\n", nonFlagArgs[1])
 		}
 
 	case "properties":
@@ -190,8 +771,319 @@ func main() {
 	}
 }
 
+func loadConfig(path string) (config.AZStorageConfig, error) {
+	configFile, err := os.Open(path)
+	if err != nil {
+		return config.AZStorageConfig{}, err
+	}
+	defer configFile.Close() //nolint:errcheck
+
+	return config.NewFromReader(configFile)
+}
+
+func newBlobstore(azConfig config.AZStorageConfig) (client.AzBlobstore, error) {
+	storageClient, err := client.NewStorageClient(azConfig)
+	if err != nil {
+		return client.AzBlobstore{}, err
+	}
+
+	return client.New(storageClient)
+}
+
+// sourceBlobstore returns the blobstore to copy from, using the account of
+// sourceConfig and the container sourceContainer where given. It returns
+// nil if neither is given.
+func sourceBlobstore(azConfig config.AZStorageConfig, sourceConfig string, sourceContainer string) (*client.AzBlobstore, error) {
+	if sourceConfig == "" && sourceContainer == "" {
+		return nil, nil
+	}
+
+	srcConfig := azConfig
+	if sourceConfig != "" {
+		var err error
+		srcConfig, err = loadConfig(sourceConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if sourceContainer != "" {
+		srcConfig.ContainerName = sourceContainer
+	}
+
+	source, err := newBlobstore(srcConfig)
+	if err != nil {
+		return nil, err
+	}
+	return &source, nil
+}
+
+// runLocked runs command while holding lock and returns its exit code.
+// Interrupts are forwarded to the command, and the command is interrupted
+// if the lock is lost while it runs.
+func runLocked(lock *client.Lock, command string, args []string) int {
+	err := lock.Acquire()
+	fatalLog("lock", err)
+	defer func() {
+		if err := lock.Release(); err != nil {
+			log.Printf("Failed to release lock: %s", err)
+		}
+	}()
+
+	child := exec.Command(command, args...)
+	child.Stdin, child.Stdout, child.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	if err := child.Start(); err != nil {
+		log.Printf("performing operation lock: %s", err)
+		return 1
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		exited <- child.Wait()
+	}()
+
+	lost := lock.Lost()
+	lockLost := false
+	for {
+		select {
+		case sig := <-signals:
+			child.Process.Signal(sig) //nolint:errcheck
+		case err := <-lost:
+			log.Printf("Interrupting command: %s", err)
+			child.Process.Signal(os.Interrupt) //nolint:errcheck
+			lost, lockLost = nil, true
+		case err := <-exited:
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				if code := exitErr.ExitCode(); code > 0 {
+					return code
+				}
+				return 1
+			}
+			if err != nil {
+				log.Printf("performing operation lock: %s", err)
+				return 1
+			}
+			if lockLost {
+				return 1
+			}
+			return 0
+		}
+	}
+}
+
+// mirror makes a prefix of the destination account identical to the source
+// account. The destination config defaults to configPath.
+func mirror(ctx context.Context, configPath string, args []string) {
+	mirrorFlags := flag.NewFlagSet("mirror", flag.ExitOnError)
+	sourceConfig := mirrorFlags.String("source-config", "", "configuration path of the account to mirror from")
+	destConfig := mirrorFlags.String("dest-config", configPath, "configuration path of the account to mirror to (default -c)")
+	concurrency := mirrorFlags.Int("concurrency", 8, "number of blobs copied at the same time")
+	deleteExtra := mirrorFlags.Bool("delete", false, "delete blobs at the destination which do not exist at the source")
+	all := mirrorFlags.Bool("all", false, "confirm --delete without a prefix, which deletes extra blobs of the whole container")
+	dryRun := mirrorFlags.Bool("dry-run", false, "print what would be copied and deleted without changing anything")
+	reportPath := mirrorFlags.String("report", "", "write a JSON report of the copied, deleted and failed blobs to this file")
+	mirrorFlags.Parse(args) //nolint:errcheck
+
+	mirrorArgs := mirrorFlags.Args()
+	if len(mirrorArgs) > 1 {
+		log.Fatalf("mirror expected at most 1 argument got %d\n", len(mirrorArgs))
+	}
+	if *sourceConfig == "" || *destConfig == "" {
+		log.Fatalln("mirror requires --source-config and --dest-config")
+	}
+	prefix := ""
+	if len(mirrorArgs) == 1 {
+		prefix = mirrorArgs[0]
+	}
+
+	blobstores := make([]client.AzBlobstore, 2)
+	for i, path := range []string{*sourceConfig, *destConfig} {
+		azConfig, err := loadConfig(path)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		blobstores[i], err = newBlobstore(azConfig)
+		if err != nil {
+			log.Fatalln(err)
+		}
+	}
+
+	options := client.MirrorOptions{
+		Concurrency: *concurrency,
+		Delete:      *deleteExtra,
+		All:         *all,
+	}
+	if *dryRun {
+		_, err := blobstores[1].DryRunMirror(&blobstores[0], prefix, options, os.Stdout)
+		fatalLog("mirror", err)
+		return
+	}
+
+	report, err := blobstores[1].Mirror(ctx, &blobstores[0], prefix, options)
+	fmt.Println(report.Summary)
+	if *reportPath != "" {
+		output, writeErr := json.MarshalIndent(report, "", "  ")
+		if writeErr == nil {
+			writeErr = os.WriteFile(*reportPath, append(output, '\n'), 0o644)
+		}
+		if writeErr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to write the report: %w", writeErr))
+		}
+	}
+	fatalLog("mirror", err)
+}
+
 func fatalLog(cmd string, err error) {
 	if err != nil {
 		log.Fatalf("performing operation %s: %s\n", cmd, err)
 	}
 }
+
+// addCopyFlags registers the options setting properties of a copy on flags
+// and returns a function building the client.CopyOptions once flags have
+// been parsed.
+func addCopyFlags(flags *flag.FlagSet) func() (client.CopyOptions, error) {
+	var metadata, tags stringList
+	flags.Var(&metadata, "metadata", "set this key=value metadata on the copy instead of the source metadata (repeatable)")
+	flags.Var(&tags, "tag", "set this key=value index tag on the copy (repeatable)")
+	tier := flags.String("tier", "", "access tier of the copy, e.g. Hot, Cool or Archive")
+	contentType := flags.String("content-type", "", "Content-Type of the copy")
+	contentEncoding := flags.String("content-encoding", "", "Content-Encoding of the copy")
+	contentLanguage := flags.String("content-language", "", "Content-Language of the copy")
+	contentDisposition := flags.String("content-disposition", "", "Content-Disposition of the copy")
+	cacheControl := flags.String("cache-control", "", "Cache-Control of the copy")
+	verifyMD5 := flags.Bool("verify-md5", false, "fail unless the MD5 of the copy matches the source")
+
+	return func() (client.CopyOptions, error) {
+		metadataValues, err := parseKeyValues(metadata)
+		if err != nil {
+			return client.CopyOptions{}, fmt.Errorf("invalid --metadata: %w", err)
+		}
+		tagValues, err := parseKeyValues(tags)
+		if err != nil {
+			return client.CopyOptions{}, fmt.Errorf("invalid --tag: %w", err)
+		}
+
+		return client.CopyOptions{
+			Metadata: metadataValues,
+			Tags:     tagValues,
+			Tier:     *tier,
+			Headers: client.ContentHeaders{
+				ContentType:        *contentType,
+				ContentEncoding:    *contentEncoding,
+				ContentLanguage:    *contentLanguage,
+				ContentDisposition: *contentDisposition,
+				CacheControl:       *cacheControl,
+			},
+			VerifyMD5: *verifyMD5,
+		}, nil
+	}
+}
+
+// parseKeyValues parses key=value pairs, returning nil for no pairs.
+func parseKeyValues(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	values := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("expected key=value, got '%s'", pair)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// addFilterFlags registers the blob filter options on flags and returns a
+// function building the client.ListFilter once flags have been parsed.
+func addFilterFlags(flags *flag.FlagSet) func() client.ListFilter {
+	var include, exclude stringList
+	flags.Var(&include, "include", "only blobs whose name matches this glob pattern (repeatable)")
+	flags.Var(&exclude, "exclude", "skip blobs whose name matches this glob pattern (repeatable)")
+	minSize := flags.String("min-size", "", "only blobs of at least this size, e.g. 512, 10K, 1G")
+	maxSize := flags.String("max-size", "", "only blobs of at most this size, e.g. 512, 10K, 1G")
+	olderThan := flags.String("older-than", "", "only blobs last modified longer ago than this, e.g. 12h, 90d")
+	newerThan := flags.String("newer-than", "", "only blobs last modified more recently than this, e.g. 12h, 90d")
+
+	return func() client.ListFilter {
+		filter := client.ListFilter{Include: include, Exclude: exclude}
+		filter.MinSize = parseSizeFlag("min-size", *minSize)
+		filter.MaxSize = parseSizeFlag("max-size", *maxSize)
+		filter.OlderThan = parseAgeFlag("older-than", *olderThan)
+		filter.NewerThan = parseAgeFlag("newer-than", *newerThan)
+		return filter
+	}
+}
+
+// filterFlagNames are the flags added by addFilterFlags.
+var filterFlagNames = []string{"include", "exclude", "min-size", "max-size", "older-than", "newer-than"}
+
+// anyFlagSet reports whether any of the named flags was passed.
+func anyFlagSet(flags *flag.FlagSet, names ...string) bool {
+	set := false
+	flags.Visit(func(f *flag.Flag) {
+		if slices.Contains(names, f.Name) {
+			set = true
+		}
+	})
+	return set
+}
+
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+var sizeSuffixes = map[string]int64{"K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40}
+
+// parseSizeFlag parses a byte count with an optional binary K, M, G or T suffix.
+func parseSizeFlag(name string, value string) int64 {
+	if value == "" {
+		return 0
+	}
+
+	multiplier := int64(1)
+	number := strings.ToUpper(value)
+	if m, ok := sizeSuffixes[number[len(number)-1:]]; ok {
+		multiplier = m
+		number = number[:len(number)-1]
+	}
+
+	size, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || size < 0 {
+		log.Fatalf("Invalid --%s '%s', expected a size such as 512, 10K or 1G\n", name, value)
+	}
+	return size * multiplier
+}
+
+// parseAgeFlag parses a duration, additionally accepting a number of days such as 90d.
+func parseAgeFlag(name string, value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if days, found := strings.CutSuffix(value, "d"); found {
+		n, err := strconv.Atoi(days)
+		if err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour
+		}
+	} else if age, err := time.ParseDuration(value); err == nil && age >= 0 {
+		return age
+	}
+
+	log.Fatalf("Invalid --%s '%s', expected a duration such as 12h or 90d\n", name, value)
+	return 0
+}